@@ -30,7 +30,7 @@ import (
 func main() {
 
 	libpak.Main(
-		maven.Detect{},
+		maven.Detect{Logger: bard.NewLogger(os.Stderr)},
 		maven.Build{
 			Logger:             bard.NewLogger(os.Stdout),
 			ApplicationFactory: libbs.NewApplicationFactory(),
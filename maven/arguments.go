@@ -0,0 +1,152 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// MavenOption is an option of the Maven command line, named by its long form (e.g. --file for -f).  The value of a
+// --define option is the name=value of the property.
+type MavenOption struct {
+	Name  string
+	Value string
+}
+
+// mavenShortOptions are the short forms of the options the buildpack also sets
+var mavenShortOptions = map[string]string{
+	"-B":  "--batch-mode",
+	"-D":  "--define",
+	"-f":  "--file",
+	"-gs": "--global-settings",
+	"-P":  "--activate-profiles",
+	"-s":  "--settings",
+	"-T":  "--threads",
+}
+
+// mavenValueOptions are the options that take a value, either attached with = or as the next argument
+var mavenValueOptions = map[string]bool{
+	"--activate-profiles": true,
+	"--define":            true,
+	"--file":              true,
+	"--global-settings":   true,
+	"--settings":          true,
+	"--threads":           true,
+}
+
+// singleValueOptions are the options of which Maven only uses one value
+var singleValueOptions = []string{"--file", "--settings", "--global-settings", "--threads"}
+
+// ParseMavenOptions returns the options of the Maven command line that the buildpack also sets.  Other options and
+// goals are left out.  Like Maven, -D, -P and -T can have their value attached to them, e.g. -Dskip=true.
+func ParseMavenOptions(args []string) []MavenOption {
+	var options []MavenOption
+	for i := 0; i < len(args); i++ {
+		name, value, attached := args[i], "", false
+
+		if strings.HasPrefix(name, "--") {
+			name, value, attached = strings.Cut(name, "=")
+			if name != "--batch-mode" && !mavenValueOptions[name] {
+				continue
+			}
+		} else if long, ok := mavenShortOptions[name]; ok {
+			name = long
+		} else if len(name) > 2 && (strings.HasPrefix(name, "-D") || strings.HasPrefix(name, "-P") || strings.HasPrefix(name, "-T")) {
+			name, value, attached = mavenShortOptions[name[:2]], name[2:], true
+		} else {
+			continue
+		}
+
+		if mavenValueOptions[name] && !attached && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		options = append(options, MavenOption{Name: name, Value: value})
+	}
+	return options
+}
+
+// OptionValues returns the values of an option, in the order they are set
+func OptionValues(options []MavenOption, name string) []string {
+	var values []string
+	for _, o := range options {
+		if o.Name == name {
+			values = append(values, o.Value)
+		}
+	}
+	return values
+}
+
+// PropertyValues returns the values a system property is defined with, in the order they are set.  A property
+// defined without a value is true.
+func PropertyValues(options []MavenOption, name string) []string {
+	var values []string
+	for _, d := range OptionValues(options, "--define") {
+		if n, v, ok := strings.Cut(d, "="); n == name && ok {
+			values = append(values, v)
+		} else if n == name {
+			values = append(values, "true")
+		}
+	}
+	return values
+}
+
+// ArgumentWarnings returns the problems of the options of the build arguments that do not prevent the build: options
+// of which Maven only uses one value that are set with different values, properties defined with different values and
+// a local repository outside of the cached repository, whose dependencies are downloaded again by every build.
+func ArgumentWarnings(options []MavenOption, repository string) []string {
+	var warnings []string
+
+	for _, name := range singleValueOptions {
+		if values := OptionValues(options, name); len(unique(values)) > 1 {
+			warnings = append(warnings, fmt.Sprintf("%s is set more than once, to %s", name, strings.Join(values, ", ")))
+		}
+	}
+
+	var properties []string
+	for _, d := range OptionValues(options, "--define") {
+		if n, _, _ := strings.Cut(d, "="); !contains(properties, []string{n}) {
+			properties = append(properties, n)
+		}
+	}
+	for _, p := range properties {
+		if values := PropertyValues(options, p); len(unique(values)) > 1 {
+			warnings = append(warnings, fmt.Sprintf("%s is defined more than once, as %s", p, strings.Join(values, ", ")))
+		}
+	}
+
+	for _, local := range PropertyValues(options, "maven.repo.local") {
+		if rel, err := filepath.Rel(repository, local); err != nil || !filepath.IsAbs(local) || strings.HasPrefix(rel, "..") {
+			warnings = append(warnings, fmt.Sprintf("maven.repo.local relocates the local repository to %s, outside of "+
+				"the cached %s, so dependencies are downloaded by every build", local, repository))
+		}
+	}
+
+	return warnings
+}
+
+func unique(values []string) []string {
+	var u []string
+	for _, v := range values {
+		if !contains(u, []string{v}) {
+			u = append(u, v)
+		}
+	}
+	return u
+}
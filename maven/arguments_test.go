@@ -0,0 +1,78 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/maven/v6/maven"
+)
+
+func testArguments(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it("parses the options the buildpack sets", func() {
+		Expect(maven.ParseMavenOptions([]string{
+			"clean", "-fae", "-f", "a/pom.xml", "--settings=s.xml", "-gs", "g.xml", "-B", "-q",
+			"-Dskip", "-D", "x=1", "-Pa,b", "-T", "2C", "--batch-mode", "package",
+		})).To(Equal([]maven.MavenOption{
+			{Name: "--file", Value: "a/pom.xml"},
+			{Name: "--settings", Value: "s.xml"},
+			{Name: "--global-settings", Value: "g.xml"},
+			{Name: "--batch-mode"},
+			{Name: "--define", Value: "skip"},
+			{Name: "--define", Value: "x=1"},
+			{Name: "--activate-profiles", Value: "a,b"},
+			{Name: "--threads", Value: "2C"},
+			{Name: "--batch-mode"},
+		}))
+	})
+
+	it("reads the values of properties", func() {
+		options := maven.ParseMavenOptions([]string{"-Dskip", "-Dx=1", "-Dx=2", "-Dxy=3"})
+
+		Expect(maven.PropertyValues(options, "skip")).To(Equal([]string{"true"}))
+		Expect(maven.PropertyValues(options, "x")).To(Equal([]string{"1", "2"}))
+	})
+
+	it("warns about conflicting options", func() {
+		Expect(maven.ArgumentWarnings(maven.ParseMavenOptions([]string{
+			"-f", "a.xml", "--file=b.xml", "-s", "s.xml", "-s", "s.xml", "-Dx=1", "-Dx=2", "-Dy=1", "-Dy=1",
+		}), "/home/cnb/.m2/repository")).To(Equal([]string{
+			"--file is set more than once, to a.xml, b.xml",
+			"x is defined more than once, as 1, 2",
+		}))
+	})
+
+	it("warns about a local repository outside of the cached one", func() {
+		Expect(maven.ArgumentWarnings(maven.ParseMavenOptions([]string{
+			"-Dmaven.repo.local=/home/cnb/.m2/repository/nested",
+		}), "/home/cnb/.m2/repository")).To(BeEmpty())
+
+		Expect(maven.ArgumentWarnings(maven.ParseMavenOptions([]string{
+			"-Dmaven.repo.local=.repository",
+		}), "/home/cnb/.m2/repository")).To(Equal([]string{
+			"maven.repo.local relocates the local repository to .repository, outside of the cached " +
+				"/home/cnb/.m2/repository, so dependencies are downloaded by every build",
+		}))
+	})
+}
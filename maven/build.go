@@ -24,6 +24,8 @@ import (
 	"os"
 	"os/user"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/paketo-buildpacks/libpak/sbom"
 
@@ -41,6 +43,7 @@ type Build struct {
 	ApplicationFactory ApplicationFactory
 	TTY                bool
 	configResolver     libpak.ConfigurationResolver
+	projectPath        string
 	depResolver        libpak.DependencyResolver
 	depCache           libpak.DependencyCache
 }
@@ -74,31 +77,95 @@ func (b Build) Build(context libcnb.BuildContext) (libcnb.BuildResult, error) {
 
 	pr := libpak.PlanEntryResolver{Plan: context.Plan}
 
+	var userSet bool
+	b.projectPath, userSet, err = ProjectPath(context.Application.Path, b.configResolver)
+	if err != nil {
+		return libcnb.BuildResult{}, fmt.Errorf("unable to resolve project path\n%w", err)
+	}
+	if _, ok := b.configResolver.Resolve("BP_MAVEN_POM_FILE"); !userSet && !ok {
+		// the project of a POM found by detection is built in its directory
+		if e, found, err := pr.Resolve(PlanEntryMaven); err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unable to resolve Maven plan entry\n%w", err)
+		} else if file, ok := e.Metadata[PlanMetadataPOMFile].(string); found && ok && file != "" {
+			b.projectPath = filepath.Join(context.Application.Path, filepath.Dir(file))
+			userSet = true
+		}
+	}
+	if userSet {
+		b.Logger.Bodyf("Building the project in %s", b.projectPath)
+	}
+
+	dryRun := b.configResolver.ResolveBool("BP_MAVEN_DRY_RUN")
+
+	var timer *BuildTimer
+	if b.configResolver.ResolveBool("BP_MAVEN_TIMING_ENABLED") {
+		timer = NewBuildTimer()
+	}
+
 	// install Maven, if needed
-	var command string
+	var (
+		command     string
+		manager     MavenManager
+		version     string
+		environment = map[string]string{}
+	)
 	if _, found, err := pr.Resolve(PlanEntryMaven); err != nil {
 		return libcnb.BuildResult{}, fmt.Errorf("unable to resolve Maven plan entry\n%w", err)
 	} else if found {
 		var layer libcnb.LayerContributor
 		var be *libcnb.BOMEntry
 
-		command, layer, be, err = b.installMaven(context)
+		stop := func() {}
+		if timer != nil {
+			stop = timer.Time(PhaseMavenSelection)
+		}
+		manager, err = b.selectMavenManager(context)
 		if err != nil {
 			return libcnb.BuildResult{}, fmt.Errorf("unable to install Maven\n%w", err)
 		}
+		if w, ok := manager.(WrapperMavenManager); ok && dryRun {
+			// preparing the wrapper changes the application, which a dry run leaves as it is
+			command = w.Command()
+		} else if command, layer, be, err = manager.Install(); err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unable to install Maven\n%w", err)
+		}
+		stop()
+
+		if dist, ok := layer.(Distribution); ok {
+			if layer, err = b.verifyDistribution(context, dist); err != nil {
+				return libcnb.BuildResult{}, fmt.Errorf("unable to configure Maven signature verification\n%w", err)
+			}
+		}
+
+		if i, ok := layer.(mavenInstallation); ok {
+			environment = MavenEnvironment(i.MavenHome(context.Layers.Path))
+		}
 
 		if layer != nil {
+			if timer != nil {
+				layer = TimedLayerContributor{LayerContributor: layer, Phase: PhaseMavenInstallation, Timer: timer}
+			}
 			result.Layers = append(result.Layers, layer)
 		}
 
 		if be != nil {
 			result.BOM.Entries = append(result.BOM.Entries, *be)
+			version, _ = be.Metadata["version"].(string)
+		} else if w, ok := manager.(WrapperMavenManager); ok {
+			version = w.Version()
 		}
 	} else {
-		command, _, _, err = NewNoopMavenManager(b.Logger).Install()
+		manager = NewNoopMavenManager(b.configResolver, b.Logger)
+		var be *libcnb.BOMEntry
+		command, _, be, err = manager.Install()
 		if err != nil {
 			return libcnb.BuildResult{}, fmt.Errorf("unable pick Maven command\n%w", err)
 		}
+
+		if be != nil {
+			result.BOM.Entries = append(result.BOM.Entries, *be)
+			version, _ = be.Metadata["version"].(string)
+		}
 	}
 
 	// setup Maven
@@ -109,13 +176,60 @@ func (b Build) Build(context libcnb.BuildContext) (libcnb.BuildResult, error) {
 
 	c := libbs.Cache{Path: filepath.Join(u.HomeDir, ".m2")}
 	c.Logger = b.Logger
-	result.Layers = append(result.Layers, c)
+	if timer != nil {
+		result.Layers = append(result.Layers, TimedLayerContributor{LayerContributor: c, Phase: PhaseCacheSetup, Timer: timer})
+	} else {
+		result.Layers = append(result.Layers, c)
+	}
 
-	art, md, args, err := b.configureMaven(context)
+	if b.configResolver.ResolveBool("BP_MAVEN_BUILD_CACHE_ENABLED") {
+		var bc libcnb.LayerContributor = BuildCache{Logger: b.Logger}
+		if timer != nil {
+			bc = TimedLayerContributor{LayerContributor: bc, Phase: PhaseCacheSetup, Timer: timer}
+		}
+		result.Layers = append(result.Layers, bc)
+	}
+
+	targetCache := b.configResolver.ResolveBool("BP_MAVEN_TARGET_CACHE_ENABLED")
+	jdk := ""
+	if targetCache {
+		// classes compiled by another JDK could be restored without it being noticed
+		if jdk = jdkVersion(); jdk == "" {
+			b.Logger.Body("Not caching target directories, the version of the JDK at $JAVA_HOME is unknown")
+			targetCache = false
+		}
+	}
+	if targetCache {
+		tc, err := NewTargetCache(b.projectPath, jdk, version)
+		if err != nil {
+			return libcnb.BuildResult{}, fmt.Errorf("unable to create target cache\n%w", err)
+		}
+		tc.Logger = b.Logger
+		var l libcnb.LayerContributor = tc
+		if timer != nil {
+			l = TimedLayerContributor{LayerContributor: l, Phase: PhaseCacheSetup, Timer: timer}
+		}
+		result.Layers = append(result.Layers, l)
+	}
+
+	native := b.nativeImage(b.projectPath)
+	art, md, args, err := b.configureMaven(context, native)
 	if err != nil {
 		return libcnb.BuildResult{}, fmt.Errorf("unable to setup Maven\n%w", err)
 	}
+	args, mavenOpts := b.configureResources(b.projectPath, manager, args)
+	if mavenOpts != "" {
+		environment["MAVEN_OPTS"] = mavenOpts
+	}
+
+	reportPath, _ := b.configResolver.Resolve("BP_MAVEN_REPORT_PATH")
+	var summary *BuildSummary
+	if reportPath != "" {
+		summary = NewBuildSummary(manager, version, command, args, md)
+		summary.Timings = timer
+	}
 
+	var reports []Report
 	if _, found, err := pr.Resolve(PlanEntryJVMApplicationPackage); err != nil {
 		return libcnb.BuildResult{}, fmt.Errorf("unable to resolve JVM Application Package plan entry\n%w", err)
 	} else if found {
@@ -137,69 +251,272 @@ func (b Build) Build(context libcnb.BuildContext) (libcnb.BuildResult, error) {
 		}
 
 		a.Logger = b.Logger
-		result.Layers = append(result.Layers, a)
+		if len(environment) > 0 {
+			a.Executor = EnvironmentExecutor{Delegate: a.Executor, Environment: environment}
+		}
+		if b.projectPath != context.Application.Path {
+			a.Executor = WorkingDirectoryExecutor{Delegate: a.Executor, Directory: b.projectPath}
+		}
+		if _, ok := manager.(DaemonMavenManager); ok {
+			a.Executor = MvndStoppingExecutor{Delegate: a.Executor, Logger: b.Logger}
+		}
+		if _, ok := b.configResolver.Resolve("BP_MAVEN_BUILT_ARTIFACT"); native != nil && !ok {
+			a.Executor = NativeArchivingExecutor{
+				Delegate:  a.Executor,
+				Directory: filepath.Dir(filepath.Join(context.Application.Path, art.Pattern())),
+				Logger:    b.Logger,
+			}
+		} else if pattern := filepath.Join(context.Application.Path, art.Pattern()); !ok && strings.Contains(filepath.Base(pattern), "*") {
+			// the Spring Boot archive cannot be told apart from the others built next to it by its name
+			if pom, err := ReadPOM(pomFile(filepath.Dir(filepath.Dir(pattern)))); err == nil && pom.IsSpringBoot() {
+				a.Executor = SecondaryArchiveRemovingExecutor{Delegate: a.Executor, Logger: b.Logger, Pattern: pattern}
+			}
+		}
+		if targetCache {
+			a.Executor = TargetCachingExecutor{
+				ApplicationPath: b.projectPath,
+				Delegate:        a.Executor,
+				LayerPath:       filepath.Join(context.Layers.Path, TargetCache{}.Name()),
+				Logger:          b.Logger,
+			}
+		}
+		if timer != nil {
+			a.Executor = TimedExecutor{Delegate: a.Executor, Timer: timer}
+		}
+		if summary != nil {
+			a.Executor = ReportingExecutor{ArtifactResolver: art, Delegate: a.Executor, Summary: summary}
+		}
+		if timer != nil {
+			result.Layers = append(result.Layers, TimedLayerContributor{LayerContributor: a, Remainder: PhasePostBuild, Timer: timer})
+		} else {
+			result.Layers = append(result.Layers, a)
+		}
+		if summary != nil {
+			reports = append(reports, BuildReport{Logger: b.Logger, Path: reportPath, Summary: summary})
+		}
+	}
+
+	if timer != nil {
+		path, _ := b.configResolver.Resolve("BP_MAVEN_TIMING_REPORT_PATH")
+		reports = append(reports, TimingReport{Logger: b.Logger, Path: path, Timer: timer})
+	}
+	if len(reports) > 0 {
+		last := len(result.Layers) - 1
+		result.Layers[last] = ReportingLayerContributor{LayerContributor: result.Layers[last], Reports: reports}
+	}
+
+	if dryRun {
+		b.explain(manager, command, args, environment, art, b.projectPath, result.Layers)
+		return keepLayers(context.Layers, result.Layers), nil
 	}
 
 	return result, nil
 }
 
-func (b Build) installMaven(context libcnb.BuildContext) (string, libcnb.LayerContributor, *libcnb.BOMEntry, error) {
+// explain logs what a build would do, without contributing any layers
+func (b Build) explain(manager MavenManager, command string, args []string, environment map[string]string, art libbs.ArtifactResolver, applicationPath string, layers []libcnb.LayerContributor) {
+	b.Logger.Header("Dry run, Maven will not be executed")
+	b.Logger.Bodyf("Maven manager: %s", managerName(manager))
+	b.Logger.Bodyf("Command: %s %s", command, strings.Join(args, " "))
+	b.Logger.Bodyf("Working directory: %s", applicationPath)
+	b.Logger.Bodyf("Artifact pattern: %s", art.Pattern())
+
+	var names []string
+	for _, l := range layers {
+		names = append(names, l.Name())
+	}
+	b.Logger.Bodyf("Layers: %s", strings.Join(names, ", "))
+
+	b.Logger.Body("Environment:")
+	var env []string
+	for k, v := range environment {
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(env)
+	for _, e := range env {
+		b.Logger.Bodyf("  %s", e)
+	}
+}
+
+// UnchangedLayer is an implementation of libcnb.LayerContributor that keeps a layer of a previous build as it is
+type UnchangedLayer struct {
+	LayerName string
+}
+
+func (u UnchangedLayer) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
+	return layer, nil
+}
+
+func (u UnchangedLayer) Name() string {
+	return u.LayerName
+}
+
+// keepLayers returns a result keeping the layers of a previous build that contributors would have contributed, as
+// libcnb removes the layers a build does not contribute
+func keepLayers(layers libcnb.Layers, contributors []libcnb.LayerContributor) libcnb.BuildResult {
+	result := libcnb.NewBuildResult()
+	for _, c := range contributors {
+		if _, err := os.Stat(filepath.Join(layers.Path, fmt.Sprintf("%s.toml", c.Name()))); err == nil {
+			result.Layers = append(result.Layers, UnchangedLayer{LayerName: c.Name()})
+		}
+	}
+	return result
+}
+
+// mavenInstallation is a layer that installs Maven, so that the build can point MAVEN_HOME at it
+type mavenInstallation interface {
+	MavenHome(layersPath string) string
+}
+
+// verifyDistribution configures a Maven distribution to be verified against its signature, if a maven-keys binding
+// provides the keys to trust.  The signature is expected next to the distribution, including when a dependency
+// mapping points at a mirror.
+func (b Build) verifyDistribution(context libcnb.BuildContext, dist Distribution) (Distribution, error) {
+	binding, ok, err := bindings.ResolveOne(context.Platform.Bindings, bindings.OfType("maven-keys"))
+	if err != nil {
+		return Distribution{}, fmt.Errorf("unable to resolve binding\n%w", err)
+	} else if !ok {
+		return dist, nil
+	}
+
+	keys, ok := binding.SecretFilePath("KEYS")
+	if !ok {
+		return Distribution{}, fmt.Errorf("binding %s does not contain a KEYS file", binding.Name)
+	}
+
+	uri := dist.LayerContributor.Dependency.URI
+	if mapped, ok := b.depCache.Mappings[dist.LayerContributor.Dependency.SHA256]; ok {
+		uri = mapped
+	}
+
+	verifier, err := NewSignatureVerifier(keys, uri+".asc")
+	if err != nil {
+		return Distribution{}, err
+	}
+	dist.Verifier = &verifier
+
+	return dist, nil
+}
+
+func (b Build) selectMavenManager(context libcnb.BuildContext) (MavenManager, error) {
 	// be careful changing this, the order does matter to a degree
 	managers := []MavenManager{
-		NewDaemonMavenManager(b.configResolver, b.depResolver, b.depCache, context.Layers.Path, b.Logger),
-		NewStandardMavenManager(context.Application.Path, b.configResolver, b.depResolver, b.depCache, context.Layers.Path, b.Logger),
-		NewWrapperMavenManager(context.Application.Path, b.Logger),
-		NewNoopMavenManager(b.Logger),
+		NewDaemonMavenManager(b.projectPath, b.configResolver, b.depResolver, b.depCache, context.Layers.Path, b.Logger),
+		NewStandardMavenManager(b.projectPath, b.configResolver, b.depResolver, b.depCache, context.Layers.Path, b.Logger),
+		NewWrapperMavenManager(b.projectPath, b.Logger),
+		NewNoopMavenManager(b.configResolver, b.Logger),
 	}
 
 	for _, manager := range managers {
 		if manager.ShouldInstall() {
-			return manager.Install()
+			return manager, nil
 		}
 	}
 
-	return "", nil, nil, fmt.Errorf("unable to install Maven")
+	return nil, fmt.Errorf("unable to install Maven")
 }
 
-func (b Build) configureMaven(context libcnb.BuildContext) (libbs.ArtifactResolver, map[string]interface{}, []string, error) {
+func (b Build) configureMaven(context libcnb.BuildContext, native *POM) (libbs.ArtifactResolver, map[string]interface{}, []string, error) {
 	args, err := libbs.ResolveArguments("BP_MAVEN_BUILD_ARGUMENTS", b.configResolver)
 	if err != nil {
 		return libbs.ArtifactResolver{}, map[string]interface{}{}, []string{}, fmt.Errorf("unable to resolve build arguments\n%w", err)
 	}
 
+	additionalArgs, err := libbs.ResolveArguments("BP_MAVEN_ADDITIONAL_BUILD_ARGUMENTS", b.configResolver)
+	if err != nil {
+		return libbs.ArtifactResolver{}, map[string]interface{}{}, []string{},
+			fmt.Errorf("unable to resolve additionnal build arguments\n%w", err)
+	}
+
+	// options set by the build arguments take precedence over the ones the buildpack sets
+	options := ParseMavenOptions(append(append([]string{}, args...), additionalArgs...))
+	for _, w := range ArgumentWarnings(options, localRepository()) {
+		b.Logger.Bodyf("WARNING: %s in the build arguments", w)
+	}
+
+	if b.configResolver.ResolveBool("BP_MAVEN_SPRING_BOOT_BUILD_INFO") && !contains(args, []string{SpringBootBuildInfoGoal}) {
+		if pom, err := ReadPOM(b.rootPOM(b.projectPath)); err == nil && pom.IsSpringBoot() {
+			args = BuildInfoArguments(args)
+		}
+	}
+
 	pomFile, userSet := b.configResolver.Resolve("BP_MAVEN_POM_FILE")
-	if userSet {
+	if files := OptionValues(options, "--file"); len(files) > 0 {
+		if userSet && filepath.Clean(files[0]) != filepath.Clean(pomFile) {
+			return libbs.ArtifactResolver{}, map[string]interface{}{}, []string{},
+				fmt.Errorf("unable to use $BP_MAVEN_POM_FILE %s and the build arguments --file %s, set only one of them", pomFile, files[0])
+		}
+		// the rest of the build reads the POM Maven is run with
+		b.configResolver = withDefault(b.configResolver, "BP_MAVEN_POM_FILE", files[0])
+	} else if userSet {
 		args = append([]string{"--file", pomFile}, args...)
+	} else if _, err := os.Stat(filepath.Join(b.projectPath, "pom.xml")); os.IsNotExist(err) {
+		polyglotPOM, ok, err := FindPolyglotPOM(b.projectPath)
+		if err != nil {
+			return libbs.ArtifactResolver{}, map[string]interface{}{}, []string{}, fmt.Errorf("unable to find polyglot POM\n%w", err)
+		} else if ok {
+			b.Logger.Bodyf("Using Polyglot Maven POM %s", polyglotPOM)
+			args = append([]string{"--file", polyglotPOM}, args...)
+		}
 	}
 
-	if !b.TTY && !contains(args, []string{"-B", "--batch-mode"}) {
+	if !b.TTY && len(OptionValues(options, "--batch-mode")) == 0 {
 		// terminal is not tty, and the user did not set batch mode; let's set it
 		args = append([]string{"--batch-mode"}, args...)
 	}
 
 	md := map[string]interface{}{}
-	if binding, ok, err := bindings.ResolveOne(context.Platform.Bindings, bindings.OfType("maven")); err != nil {
+	binding, ok, err := bindings.ResolveOne(context.Platform.Bindings, bindings.OfType("maven"))
+	if err != nil {
 		return libbs.ArtifactResolver{}, map[string]interface{}{}, []string{}, fmt.Errorf("unable to resolve binding\n%w", err)
+	}
+	settingsPath, _ := b.configResolver.Resolve("BP_MAVEN_SETTINGS_PATH")
+	if settings := OptionValues(options, "--settings"); len(settings) > 0 {
+		if _, found := binding.SecretFilePath("settings.xml"); ok && found {
+			b.Logger.Bodyf("WARNING: the settings.xml of the maven binding is not used, the build arguments set --settings %s", settings[0])
+		} else if !ok && settingsPath != "" && settingsPath != settings[0] {
+			return libbs.ArtifactResolver{}, map[string]interface{}{}, []string{},
+				fmt.Errorf("unable to use $BP_MAVEN_SETTINGS_PATH %s and the build arguments --settings %s, set only one of them", settingsPath, settings[0])
+		}
 	} else if ok {
 		args, err = handleMavenSettings(binding, args, md)
 		if err != nil {
 			return libbs.ArtifactResolver{}, map[string]interface{}{}, []string{}, fmt.Errorf("unable to process maven settings from binding\n%w", err)
 		}
-	} else if !ok {
-		settingsPath, _ := b.configResolver.Resolve("BP_MAVEN_SETTINGS_PATH")
-		if settingsPath != "" {
-			args = append([]string{fmt.Sprintf("--settings=%s", settingsPath)}, args...)
+	} else if settingsPath != "" {
+		args = append([]string{fmt.Sprintf("--settings=%s", settingsPath)}, args...)
+	}
+
+	var extensions Extensions
+	if binding, ok, err := bindings.ResolveOne(context.Platform.Bindings, bindings.OfType("maven-extensions")); err != nil {
+		return libbs.ArtifactResolver{}, map[string]interface{}{}, []string{}, fmt.Errorf("unable to resolve binding\n%w", err)
+	} else if ok {
+		if extensionsPath, ok := binding.SecretFilePath("extensions.xml"); ok {
+			if extensions, _, err = ReadExtensions(extensionsPath); err != nil {
+				return libbs.ArtifactResolver{}, map[string]interface{}{}, []string{}, fmt.Errorf("unable to read maven extensions from binding\n%w", err)
+			}
 		}
 	}
 
-	additionalArgs, err := libbs.ResolveArguments("BP_MAVEN_ADDITIONAL_BUILD_ARGUMENTS", b.configResolver)
-	if err != nil {
-		return libbs.ArtifactResolver{}, map[string]interface{}{}, []string{},
-			fmt.Errorf("unable to resolve additionnal build arguments\n%w", err)
-	} else {
-		args = append(args, additionalArgs...)
+	if b.configResolver.ResolveBool("BP_MAVEN_BUILD_CACHE_ENABLED") {
+		version, _ := b.configResolver.Resolve("BP_MAVEN_BUILD_CACHE_VERSION")
+		extensions, _ = extensions.Merge(BuildCacheExtension(version))
+
+		args = append(args,
+			"-Dmaven.build.cache.enabled=true",
+			fmt.Sprintf("-Dmaven.build.cache.location=%s", filepath.Join(context.Layers.Path, BuildCache{}.Name())),
+		)
 	}
 
+	// the extensions are added at once, so that the digest is the one of the extensions Maven runs with
+	if len(extensions.Extensions) > 0 {
+		if err := b.addExtensions(extensions, b.projectPath, md); err != nil {
+			return libbs.ArtifactResolver{}, map[string]interface{}{}, []string{}, fmt.Errorf("unable to add maven extensions\n%w", err)
+		}
+	}
+
+	args = append(args, additionalArgs...)
+
 	profiles, err := libbs.ResolveArguments("BP_MAVEN_ACTIVE_PROFILES", b.configResolver)
 	if err != nil {
 		return libbs.ArtifactResolver{}, map[string]interface{}{}, []string{},
@@ -209,12 +526,237 @@ func (b Build) configureMaven(context libcnb.BuildContext) (libbs.ArtifactResolv
 		args = append(args, profiles...)
 	}
 
-	return libbs.ArtifactResolver{
-		ArtifactConfigurationKey: "BP_MAVEN_BUILT_ARTIFACT",
+	if args, err = b.activateProfiles(context.Plan, args); err != nil {
+		return libbs.ArtifactResolver{}, map[string]interface{}{}, []string{}, fmt.Errorf("unable to activate profiles\n%w", err)
+	}
+
+	if b.configResolver.ResolveBool("BP_JAVA_INSTALL_NODE") {
+		args = b.skipNodeInstallation(b.projectPath, args)
+	}
+
+	if native != nil {
+		args = NativeArguments(*native, args)
+	}
+
+	// a Polyglot Maven POM cannot be read, so its profiles cannot be validated
+	if projects := ReadReactor(b.rootPOM(b.projectPath)); len(projects) > 0 {
+		if undeclared := UndeclaredProfiles(projects, activeProfiles(args)); len(undeclared) > 0 {
+			b.Logger.Bodyf("WARNING: the profiles %s are not declared by the POMs of the project, unless a parent outside of "+
+				"the project or the Maven settings declare them they will not be activated", strings.Join(undeclared, ", "))
+		}
+	}
+
+	return b.artifactResolver(context.Application.Path, native != nil, args), md, args, nil
+}
+
+// activateProfiles activates the profiles requested by other buildpacks in the plan, and the cloud-native profile if a
+// project of the reactor declares it.  Profiles the arguments already activate or deactivate are left as they are.
+func (b Build) activateProfiles(plan libcnb.BuildpackPlan, args []string) ([]string, error) {
+	requested, err := PlanProfiles(plan)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range ReadReactor(b.rootPOM(b.projectPath)) {
+		if p.POM.HasProfile(CloudNativeProfile) && !contains(requested, []string{CloudNativeProfile}) {
+			requested = append(requested, CloudNativeProfile)
+		}
+	}
+
+	var profiles []string
+	for _, p := range requested {
+		if !mentionsProfile(activeProfiles(args), profileID(p)) {
+			profiles = append(profiles, p)
+		}
+	}
+	if len(profiles) == 0 {
+		return args, nil
+	}
+
+	b.Logger.Bodyf("Activating the profiles %s", strings.Join(profiles, ", "))
+	return append(args, "-P", strings.Join(profiles, ",")), nil
+}
+
+// nativeImage returns the root POM if a native image is requested and the project is configured to build one
+func (b Build) nativeImage(projectPath string) *POM {
+	if !NativeImageRequested(b.configResolver) {
+		return nil
+	}
+
+	pom, err := ReadPOM(b.rootPOM(projectPath))
+	if err != nil || !pom.IsNative() {
+		b.Logger.Bodyf("WARNING: a native image is requested but the POM does not configure the %s or a %s profile",
+			NativeArtifactID, NativeProfile)
+		return nil
+	}
+
+	return &pom
+}
+
+// builtArtifactKey is the configuration the built artifact patterns, relative to the root of the application, are
+// resolved with.  libbs resolves a configured artifact or module against the root of the application, while
+// $BP_MAVEN_BUILT_ARTIFACT and $BP_MAVEN_BUILT_MODULE are relative to the project.
+const builtArtifactKey = "BP_MAVEN_RESOLVED_BUILT_ARTIFACT"
+
+// artifactResolver finds the built artifact in the configured module of the project.  If neither the module nor the
+// artifact are configured and a 4.1.0 model root POM only aggregates other projects, the single project among them that
+// packages something is used instead of the root.  A native build's artifact is the archived native executable and a
+// Spring Boot application's artifact is the archive repackaged by the Spring Boot Maven plugin.  A Quarkus
+// application's artifacts depend on its packaging type.
+func (b Build) artifactResolver(applicationPath string, native bool, args []string) libbs.ArtifactResolver {
+	art := libbs.ArtifactResolver{
+		ArtifactConfigurationKey: builtArtifactKey,
 		ConfigurationResolver:    b.configResolver,
-		ModuleConfigurationKey:   "BP_MAVEN_BUILT_MODULE",
 		InterestingFileDetector:  libbs.JARInterestingFileDetector{},
-	}, md, args, nil
+	}
+
+	project := ""
+	if dir, err := filepath.Rel(applicationPath, b.projectPath); err == nil && dir != "." {
+		// the artifacts of a project in a subdirectory are built there, even if its POM cannot be read
+		project = dir
+	}
+
+	if pattern, ok := b.configResolver.Resolve("BP_MAVEN_BUILT_ARTIFACT"); ok {
+		return withPatterns(art, project, strings.Fields(pattern))
+	}
+
+	module, userSet := b.configResolver.Resolve("BP_MAVEN_BUILT_MODULE")
+	selected := project
+
+	file := b.rootPOM(b.projectPath)
+	root, err := ReadPOM(file)
+	if !userSet && root.IsAggregator() {
+		var modules []string
+		for _, p := range root.ProjectPaths(filepath.Dir(file)) {
+			f := pomFile(filepath.Join(filepath.Dir(file), p))
+			if pom, err := ReadPOM(f); err == nil && !pom.IsAggregator() {
+				if module, err := filepath.Rel(b.projectPath, filepath.Dir(f)); err == nil {
+					modules = append(modules, module)
+				}
+			}
+		}
+
+		if len(modules) == 1 && root.IsMaven4() {
+			selected = filepath.Join(project, modules[0])
+			b.Logger.Bodyf("Looking for the built artifact in the %s module, the only one the root POM aggregates", modules[0])
+		} else if len(modules) > 0 {
+			art.AdditionalHelpMessage = fmt.Sprintf("The root POM aggregates other modules, set $BP_MAVEN_BUILT_MODULE to the one "+
+				"that builds the application: %s", strings.Join(modules, ", "))
+		}
+	}
+
+	pom := root
+	if userSet {
+		selected = filepath.Join(project, module)
+		file = pomFile(filepath.Join(b.projectPath, module))
+		pom, err = ReadPOM(file)
+	} else if filepath.Join(applicationPath, selected) != b.projectPath {
+		file = pomFile(filepath.Join(applicationPath, selected))
+		pom, err = ReadPOM(file)
+	}
+
+	pattern, _ := b.configResolver.Resolve("BP_MAVEN_BUILT_ARTIFACT")
+	if native {
+		pattern = filepath.Join("target", NativeArchive)
+	} else if err == nil && pom.IsSpringBoot() {
+		pattern = SpringBootArtifactPattern(pom)
+		b.Logger.Bodyf("Looking for the Spring Boot executable archive %s", pattern)
+	} else if err == nil && pom.IsQuarkus() {
+		if packageType, err := QuarkusPackageType(pom, filepath.Dir(file), args); err != nil {
+			b.Logger.Bodyf("WARNING: unable to determine the Quarkus packaging type\n%s", err)
+		} else if p, ok := QuarkusArtifactPatterns[packageType]; ok {
+			pattern = p
+			b.Logger.Bodyf("Looking for the Quarkus %s artifacts %s", packageType, pattern)
+		} else {
+			b.Logger.Bodyf("WARNING: unsupported Quarkus packaging type %s, set $BP_MAVEN_BUILT_ARTIFACT", packageType)
+		}
+	}
+
+	return withPatterns(art, selected, strings.Fields(pattern))
+}
+
+// withPatterns returns a copy of art that resolves patterns relative to the dir of the application
+func withPatterns(art libbs.ArtifactResolver, dir string, patterns []string) libbs.ArtifactResolver {
+	for i := range patterns {
+		patterns[i] = filepath.Join(dir, patterns[i])
+	}
+	art.ConfigurationResolver = withDefault(art.ConfigurationResolver, art.ArtifactConfigurationKey,
+		strings.Join(patterns, " "))
+	return art
+}
+
+// skipNodeInstallation skips the download of Node by the frontend-maven-plugin, which is installed by another
+// buildpack instead
+func (b Build) skipNodeInstallation(projectPath string, args []string) []string {
+	for _, e := range FrontendExecutions(ReadReactor(b.rootPOM(projectPath))) {
+		if e.SkipInstall == "" || setsProperty(args, e.SkipInstall) {
+			continue
+		}
+		b.Logger.Bodyf("Skipping the installation of Node by the %s in %s", FrontendArtifactID, e.Directory)
+		args = append(args, fmt.Sprintf("-D%s", e.SkipInstall))
+	}
+	return args
+}
+
+// setsProperty determines if the arguments set a system property
+func setsProperty(args []string, name string) bool {
+	for _, a := range args {
+		if a == fmt.Sprintf("-D%s", name) || strings.HasPrefix(a, fmt.Sprintf("-D%s=", name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// rootPOM returns the POM file Maven is run with in the project
+func (b Build) rootPOM(projectPath string) string {
+	file, _ := b.configResolver.Resolve("BP_MAVEN_POM_FILE")
+	return pomFile(filepath.Join(projectPath, file))
+}
+
+// withDefault returns a copy of resolver with a different default for a configuration
+func withDefault(resolver libpak.ConfigurationResolver, name string, value string) libpak.ConfigurationResolver {
+	configurations := []libpak.BuildpackConfiguration{{Name: name, Default: value}}
+	for _, c := range resolver.Configurations {
+		if c.Name != name {
+			configurations = append(configurations, c)
+		}
+	}
+	return libpak.ConfigurationResolver{Configurations: configurations}
+}
+
+// configureResources sizes Maven to the limits of the build container, unless the project or the user already do.
+// It returns the arguments with any thread count added and the MAVEN_OPTS to run Maven with, if they need changing.
+func (b Build) configureResources(projectPath string, manager MavenManager, args []string) ([]string, string) {
+	if _, ok := manager.(DaemonMavenManager); ok {
+		// the daemon is sized through its own properties
+		return args, ""
+	}
+
+	resources := DetectContainerResources(CgroupRoot)
+
+	threads, _ := b.configResolver.Resolve("BP_MAVEN_THREADS")
+	if threads == "" {
+		threads = resources.Threads()
+	}
+	mavenConfig, _ := os.ReadFile(filepath.Join(projectPath, ".mvn", "maven.config"))
+	if threads != "" && threads != "1" && !setsThreads(args) && !setsThreads(strings.Fields(string(mavenConfig))) {
+		b.Logger.Bodyf("Building with %s threads", threads)
+		args = append([]string{"-T", threads}, args...)
+	}
+
+	if opts, _ := b.configResolver.Resolve("BP_MAVEN_OPTS"); opts != "" {
+		return args, opts
+	}
+
+	existing := os.Getenv("MAVEN_OPTS")
+	jvmConfig, _ := os.ReadFile(filepath.Join(projectPath, ".mvn", "jvm.config"))
+	if heap := resources.MavenOpts(); heap != "" && !setsHeap(existing) && !setsHeap(string(jvmConfig)) {
+		b.Logger.Bodyf("Sizing Maven heap to %d%% of the %d MiB memory limit", HeapPercentage, resources.MemoryLimit/1024/1024)
+		return args, strings.TrimSpace(fmt.Sprintf("%s %s", existing, heap))
+	}
+
+	return args, ""
 }
 
 func handleMavenSettings(binding libcnb.Binding, args []string, md map[string]interface{}) ([]string, error) {
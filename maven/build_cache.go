@@ -0,0 +1,59 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak/bard"
+)
+
+const (
+	BuildCacheExtensionGroupID    = "org.apache.maven.extensions"
+	BuildCacheExtensionArtifactID = "maven-build-cache-extension"
+)
+
+// BuildCache provides the cache layer the Maven build cache extension stores its local cache in
+type BuildCache struct {
+	Logger bard.Logger
+}
+
+func (b BuildCache) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
+	if err := os.MkdirAll(layer.Path, 0755); err != nil {
+		return libcnb.Layer{}, fmt.Errorf("unable to create layer directory %s\n%w", layer.Path, err)
+	}
+	b.Logger.Bodyf("Using build cache %s", layer.Path)
+
+	layer.Cache = true
+	return layer, nil
+}
+
+func (BuildCache) Name() string {
+	return "build-cache"
+}
+
+// BuildCacheExtension returns the declaration of the Maven build cache extension
+func BuildCacheExtension(version string) Extensions {
+	return Extensions{Extensions: []Extension{{
+		GroupID:    BuildCacheExtensionGroupID,
+		ArtifactID: BuildCacheExtensionArtifactID,
+		Content: fmt.Sprintf("\n    <groupId>%s</groupId>\n    <artifactId>%s</artifactId>\n    <version>%s</version>\n  ",
+			BuildCacheExtensionGroupID, BuildCacheExtensionArtifactID, version),
+	}}}
+}
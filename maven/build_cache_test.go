@@ -0,0 +1,67 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/maven/v6/maven"
+)
+
+func testBuildCache(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		ctx libcnb.BuildContext
+	)
+
+	it.Before(func() {
+		var err error
+
+		ctx.Layers.Path, err = os.MkdirTemp("", "build-cache-layers")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(ctx.Layers.Path)).To(Succeed())
+	})
+
+	it("contributes a cache layer", func() {
+		layer, err := ctx.Layers.Layer("build-cache")
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err = maven.BuildCache{}.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(layer.Cache).To(BeTrue())
+		Expect(layer.Build).To(BeFalse())
+		Expect(layer.Launch).To(BeFalse())
+		Expect(layer.Path).To(BeADirectory())
+	})
+
+	it("declares the extension", func() {
+		merged, added := maven.Extensions{}.Merge(maven.BuildCacheExtension("1.2.0"))
+
+		Expect(added).To(Equal([]string{"org.apache.maven.extensions:maven-build-cache-extension"}))
+		Expect(string(merged.Bytes())).To(ContainSubstring("<version>1.2.0</version>"))
+	})
+}
@@ -17,6 +17,9 @@
 package maven_test
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -24,10 +27,14 @@ import (
 
 	"github.com/paketo-buildpacks/libpak/sbom"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
 	"github.com/buildpacks/libcnb"
 	. "github.com/onsi/gomega"
 	"github.com/paketo-buildpacks/libbs"
 	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/effect"
 	"github.com/sclevine/spec"
 
 	"github.com/paketo-buildpacks/maven/v6/maven"
@@ -64,6 +71,9 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		}
 
 		mvnwFilepath = filepath.Join(ctx.Application.Path, "mvnw")
+
+		// prevents a parallel build from being configured from the CPU quota of the test container
+		t.Setenv("BP_MAVEN_THREADS", "1")
 	})
 
 	it.After(func() {
@@ -104,6 +114,179 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 
+	context("detection found a POM in a subdirectory", func() {
+		it.Before(func() {
+			ctx.Buildpack.Metadata = map[string]interface{}{
+				"configurations": []map[string]interface{}{
+					{"name": "BP_MAVEN_BUILD_ARGUMENTS", "default": "test-argument"},
+					{"name": "BP_MAVEN_BUILT_ARTIFACT", "default": "target/*.[ejw]ar"},
+					{"name": "BP_MAVEN_POM_FILE", "default": "pom.xml"},
+				},
+			}
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "backend"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "backend", "mvnw"), []byte{}, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "backend", "pom.xml"), []byte("<project/>"), 0644)).To(Succeed())
+
+			ctx.Plan.Entries = append(ctx.Plan.Entries, libcnb.BuildpackPlanEntry{
+				Name:     "maven",
+				Metadata: map[string]interface{}{"pom-file": "backend/pom.xml"},
+			})
+		})
+
+		it("builds the project in the directory of the POM", func() {
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			application := result.Layers[1].(libbs.Application)
+			Expect(application.Command).To(Equal(filepath.Join(ctx.Application.Path, "backend", "mvnw")))
+			Expect(application.Arguments).To(Equal([]string{"test-argument"}))
+			Expect(application.Executor).To(Equal(maven.WorkingDirectoryExecutor{
+				Directory: filepath.Join(ctx.Application.Path, "backend"),
+			}))
+			Expect(application.ArtifactResolver.Pattern()).To(Equal("backend/target/*.[ejw]ar"))
+		})
+
+		it("prefers the configured POM", func() {
+			t.Setenv("BP_MAVEN_POM_FILE", "other/pom.xml")
+			Expect(os.WriteFile(mvnwFilepath, []byte{}, 0644)).To(Succeed())
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			application := result.Layers[1].(libbs.Application)
+			Expect(application.Arguments[0:2]).To(Equal([]string{"--file", "other/pom.xml"}))
+			Expect(application.Executor).To(BeNil())
+		})
+	})
+
+	context("BP_MAVEN_PROJECT_PATH is set", func() {
+		it.Before(func() {
+			ctx.Buildpack.Metadata = map[string]interface{}{
+				"configurations": []map[string]interface{}{
+					{"name": "BP_MAVEN_BUILD_ARGUMENTS", "default": "test-argument"},
+					{"name": "BP_MAVEN_BUILT_ARTIFACT", "default": "target/*.[ejw]ar"},
+					{"name": "BP_MAVEN_POM_FILE", "default": "pom.xml"},
+				},
+			}
+			t.Setenv("BP_MAVEN_PROJECT_PATH", "services/api")
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "services", "api", ".mvn"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "services", "api", "mvnw"), []byte{}, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "services", "api", "pom.xml"), []byte("<project/>"), 0644)).To(Succeed())
+			ctx.Plan.Entries = append(ctx.Plan.Entries, libcnb.BuildpackPlanEntry{Name: "maven"})
+		})
+
+		it("runs the wrapper of the project in its directory", func() {
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			application := result.Layers[1].(libbs.Application)
+			Expect(application.Command).To(Equal(filepath.Join(ctx.Application.Path, "services", "api", "mvnw")))
+			Expect(application.Executor).To(Equal(maven.WorkingDirectoryExecutor{
+				Directory: filepath.Join(ctx.Application.Path, "services", "api"),
+			}))
+			Expect(application.ArtifactResolver.Pattern()).To(Equal("services/api/target/*.[ejw]ar"))
+		})
+
+		it("caches and restores the target directories of the project", func() {
+			t.Setenv("BP_MAVEN_TARGET_CACHE_ENABLED", "true")
+			t.Setenv("JAVA_HOME", t.TempDir())
+			Expect(os.WriteFile(filepath.Join(os.Getenv("JAVA_HOME"), "release"), []byte(`JAVA_VERSION="17.0.1"`), 0644)).To(Succeed())
+			project := filepath.Join(ctx.Application.Path, "services", "api")
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[1].(maven.TargetCache).ApplicationPath).To(Equal(project))
+			executor := result.Layers[2].(libbs.Application).Executor.(maven.TargetCachingExecutor)
+			Expect(executor.ApplicationPath).To(Equal(project))
+
+			layer, err := ctx.Layers.Layer("target-cache")
+			Expect(err).NotTo(HaveOccurred())
+			layer, err = result.Layers[1].(maven.TargetCache).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Maven runs in the project, but the executor sees the directory of the application
+			Expect(os.MkdirAll(filepath.Join(project, "target"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(project, "target", "api.jar"), []byte("test"), 0644)).To(Succeed())
+			executor.Delegate = &RecordingExecutor{}
+			Expect(executor.Execute(effect.Execution{Dir: ctx.Application.Path})).To(Succeed())
+			Expect(os.RemoveAll(filepath.Join(project, "target"))).To(Succeed())
+
+			_, err = result.Layers[1].(maven.TargetCache).Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(filepath.Join(project, "target", "api.jar")).To(BeARegularFile())
+			Expect(filepath.Join(project, "services")).NotTo(BeADirectory())
+		})
+
+		it("looks for the built artifact in the project when its POM cannot be read", func() {
+			Expect(os.Remove(filepath.Join(ctx.Application.Path, "services", "api", "pom.xml"))).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "services", "api", "pom.yaml"), []byte("modelVersion: 4.0.0"), 0644)).To(Succeed())
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			application := result.Layers[1].(libbs.Application)
+			Expect(application.ArtifactResolver.Pattern()).To(Equal("services/api/target/*.[ejw]ar"))
+		})
+
+		it("looks for the built artifact in the configured module of the project", func() {
+			t.Setenv("BP_MAVEN_BUILT_MODULE", "app")
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "services", "api", "app"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "services", "api", "app", "pom.xml"), []byte(`<project>
+  <build>
+    <finalName>app</finalName>
+    <plugins>
+      <plugin>
+        <groupId>org.springframework.boot</groupId>
+        <artifactId>spring-boot-maven-plugin</artifactId>
+      </plugin>
+    </plugins>
+  </build>
+</project>`), 0644)).To(Succeed())
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			application := result.Layers[1].(libbs.Application)
+			Expect(application.ArtifactResolver.Pattern()).To(Equal("services/api/app/target/app.jar"))
+		})
+
+		it("looks for the configured artifact in the project", func() {
+			t.Setenv("BP_MAVEN_BUILT_ARTIFACT", "target/*.jar target/lib")
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			application := result.Layers[1].(libbs.Application)
+			Expect(application.ArtifactResolver.Pattern()).To(Equal("services/api/target/*.jar services/api/target/lib"))
+		})
+
+		it("passes a configured POM relative to the project", func() {
+			t.Setenv("BP_MAVEN_POM_FILE", "build.xml")
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "services", "api", "build.xml"), []byte("<project/>"), 0644)).To(Succeed())
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[1].(libbs.Application).Arguments).To(Equal([]string{"--file", "build.xml", "test-argument"}))
+		})
+
+		it("fails when the project is outside of the application", func() {
+			t.Setenv("BP_MAVEN_PROJECT_PATH", "../elsewhere")
+
+			_, err := mavenBuild.Build(ctx)
+			Expect(err).To(MatchError(ContainSubstring("outside of the application")))
+		})
+
+		it("fails when the project does not exist", func() {
+			t.Setenv("BP_MAVEN_PROJECT_PATH", "services/missing")
+
+			_, err := mavenBuild.Build(ctx)
+			Expect(err).To(MatchError(ContainSubstring("unable to find project path")))
+		})
+	})
+
 	context("BP_MAVEN_BUILD_ARGUMENTS includes --batch-mode", func() {
 		it.Before(func() {
 			Expect(os.Setenv("BP_MAVEN_BUILD_ARGUMENTS", "--batch-mode user-provided-argument")).To(Succeed())
@@ -155,6 +338,64 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 
+	context("profiles are activated from the POM and the plan", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(mvnwFilepath, []byte{}, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte(`<project>
+  <profiles>
+    <profile>
+      <id>cloud-native</id>
+    </profile>
+    <profile>
+      <id>observability</id>
+    </profile>
+  </profiles>
+</project>`), 0644)).To(Succeed())
+		})
+
+		it("activates the cloud-native profile and the profiles requested in the plan", func() {
+			ctx.Plan.Entries = append(ctx.Plan.Entries,
+				libcnb.BuildpackPlanEntry{Name: "maven", Metadata: map[string]interface{}{"profiles": []interface{}{"observability"}}},
+				libcnb.BuildpackPlanEntry{Name: "jvm-application-package", Metadata: map[string]interface{}{"profiles": "observability,?tracing"}},
+			)
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[1].(libbs.Application).Arguments).To(Equal([]string{
+				"test-argument", "-P", "observability,?tracing,cloud-native",
+			}))
+		})
+
+		it("leaves the profiles the user activates or deactivates", func() {
+			t.Setenv("BP_MAVEN_ACTIVE_PROFILES", "!cloud-native")
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[1].(libbs.Application).Arguments).To(Equal([]string{"test-argument", "-P", "!cloud-native"}))
+		})
+
+		it("warns about profiles the POM does not declare", func() {
+			t.Setenv("BP_MAVEN_ACTIVE_PROFILES", "prod")
+			out := &bytes.Buffer{}
+			mavenBuild.Logger = bard.NewLogger(out)
+
+			_, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(out.String()).To(ContainSubstring("WARNING: the profiles prod are not declared by the POMs of the project"))
+		})
+
+		it("fails when the plan requests profiles that are not strings", func() {
+			ctx.Plan.Entries = append(ctx.Plan.Entries,
+				libcnb.BuildpackPlanEntry{Name: "maven", Metadata: map[string]interface{}{"profiles": 1}})
+
+			_, err := mavenBuild.Build(ctx)
+			Expect(err).To(MatchError(ContainSubstring("unable to read profiles 1 requested by the maven plan entry")))
+		})
+	})
+
 	context("BP_MAVEN_ACTIVE_PROFILES adds active profiles", func() {
 		it.Before(func() {
 			Expect(os.Setenv("BP_MAVEN_BUILD_ARGUMENTS", "--batch-mode user-provided-argument")).To(Succeed())
@@ -229,6 +470,70 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 
+	context("the build arguments set options the buildpack sets", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(mvnwFilepath, []byte{}, 0644)).To(Succeed())
+		})
+
+		it("does not add the POM file", func() {
+			t.Setenv("BP_MAVEN_BUILD_ARGUMENTS", "-f app/pom.xml package")
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[1].(libbs.Application).Arguments).To(Equal([]string{"-f", "app/pom.xml", "package"}))
+		})
+
+		it("fails when the POM file conflicts with BP_MAVEN_POM_FILE", func() {
+			t.Setenv("BP_MAVEN_BUILD_ARGUMENTS", "--file=app/pom.xml package")
+			t.Setenv("BP_MAVEN_POM_FILE", "other/pom.xml")
+
+			_, err := mavenBuild.Build(ctx)
+			Expect(err).To(MatchError(ContainSubstring(
+				"unable to use $BP_MAVEN_POM_FILE other/pom.xml and the build arguments --file app/pom.xml")))
+		})
+
+		it("does not add the settings", func() {
+			t.Setenv("BP_MAVEN_BUILD_ARGUMENTS", "-s /workspace/settings.xml package")
+			t.Setenv("BP_MAVEN_SETTINGS_PATH", "/workspace/settings.xml")
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[1].(libbs.Application).Arguments).To(Equal([]string{"-s", "/workspace/settings.xml", "package"}))
+		})
+
+		it("fails when the settings conflict with BP_MAVEN_SETTINGS_PATH", func() {
+			t.Setenv("BP_MAVEN_BUILD_ARGUMENTS", "--settings /workspace/other.xml package")
+			t.Setenv("BP_MAVEN_SETTINGS_PATH", "/workspace/settings.xml")
+
+			_, err := mavenBuild.Build(ctx)
+			Expect(err).To(MatchError(ContainSubstring(
+				"unable to use $BP_MAVEN_SETTINGS_PATH /workspace/settings.xml and the build arguments --settings /workspace/other.xml")))
+		})
+
+		it("does not add --batch-mode when the additional arguments set it", func() {
+			t.Setenv("BP_MAVEN_ADDITIONAL_BUILD_ARGUMENTS", "-B")
+			mavenBuild.TTY = false
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[1].(libbs.Application).Arguments).To(Equal([]string{"test-argument", "-B"}))
+		})
+
+		it("warns about a local repository outside of the cache", func() {
+			t.Setenv("BP_MAVEN_BUILD_ARGUMENTS", "-Dmaven.repo.local=/tmp/repository package")
+			out := &bytes.Buffer{}
+			mavenBuild.Logger = bard.NewLogger(out)
+
+			_, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(out.String()).To(ContainSubstring("WARNING: maven.repo.local relocates the local repository to /tmp/repository"))
+		})
+	})
+
 	it("does not contribute distribution if wrapper exists", func() {
 		ctx.Plan.Entries = append(ctx.Plan.Entries, libcnb.BuildpackPlanEntry{Name: "maven"})
 
@@ -248,6 +553,29 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		Expect(result.Layers[1].(libbs.Application).Arguments).To(Equal([]string{"test-argument"}))
 	})
 
+	it("records the Maven installed at MAVEN_HOME when it does not provide Maven", func() {
+		home := t.TempDir()
+		Expect(os.MkdirAll(filepath.Join(home, "bin"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(home, "bin", "mvn"), []byte{}, 0755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(home, "lib"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(home, "lib", "maven-core-3.9.9.jar"), []byte{}, 0644)).To(Succeed())
+		t.Setenv("MAVEN_HOME", home)
+
+		result, err := mavenBuild.Build(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(result.Layers[1].(libbs.Application).Command).To(Equal(filepath.Join(home, "bin", "mvn")))
+		Expect(result.BOM.Entries).To(Equal([]libcnb.BOMEntry{{
+			Name: "maven",
+			Metadata: map[string]interface{}{
+				"name":    "Apache Maven",
+				"version": "3.9.9",
+				"path":    home,
+			},
+			Build: true,
+		}}))
+	})
+
 	it("contributes distribution", func() {
 		t.Setenv("PATH", "/does-not-exist") // prevents mvn from possibly being on the PATH
 
@@ -273,6 +601,11 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		Expect(result.Layers[2].Name()).To(Equal("application"))
 		Expect(result.Layers[2].(libbs.Application).Command).To(Equal(filepath.Join(ctx.Layers.Path, "maven", "bin", "mvn")))
 		Expect(result.Layers[2].(libbs.Application).Arguments).To(Equal([]string{"test-argument"}))
+		Expect(result.Layers[2].(libbs.Application).Executor.(maven.EnvironmentExecutor).Environment).To(And(
+			HaveKeyWithValue("MAVEN_HOME", filepath.Join(ctx.Layers.Path, "maven")),
+			HaveKeyWithValue("M2_HOME", filepath.Join(ctx.Layers.Path, "maven")),
+			HaveKey("MAVEN_REPO_LOCAL"),
+		))
 
 		Expect(result.BOM.Entries).To(HaveLen(1))
 		Expect(result.BOM.Entries[0].Name).To(Equal("maven"))
@@ -310,6 +643,54 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		Expect(result.BOM.Entries[0].Launch).To(BeFalse())
 	})
 
+	it("verifies the distribution with the keys of a maven-keys binding", func() {
+		t.Setenv("PATH", "/does-not-exist") // prevents mvn from possibly being on the PATH
+
+		ctx.Plan.Entries = append(ctx.Plan.Entries, libcnb.BuildpackPlanEntry{Name: "maven"})
+		ctx.Buildpack.Metadata["dependencies"] = []map[string]interface{}{
+			{
+				"id":      "maven",
+				"version": "1.1.1",
+				"uri":     "https://localhost/apache-maven-bin.tar.gz",
+				"stacks":  []interface{}{"test-stack-id"},
+			},
+		}
+		ctx.StackID = "test-stack-id"
+
+		var err error
+		ctx.Platform.Path, err = os.MkdirTemp("", "maven-test-platform")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(ctx.Platform.Path)
+		ctx.Platform.Bindings = libcnb.Bindings{
+			{
+				Name:   "some-keys",
+				Type:   "maven-keys",
+				Secret: map[string]string{"KEYS": ""},
+				Path:   filepath.Join(ctx.Platform.Path, "bindings", "some-keys"),
+			},
+		}
+		keysPath, ok := ctx.Platform.Bindings[0].SecretFilePath("KEYS")
+		Expect(ok).To(BeTrue())
+		Expect(os.MkdirAll(filepath.Dir(keysPath), 0777)).To(Succeed())
+
+		entity, err := openpgp.NewEntity("Test Signer", "", "signer@example.com", nil)
+		Expect(err).NotTo(HaveOccurred())
+		keys := &bytes.Buffer{}
+		w, err := armor.Encode(keys, openpgp.PublicKeyType, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entity.Serialize(w)).To(Succeed())
+		Expect(w.Close()).To(Succeed())
+		Expect(os.WriteFile(keysPath, keys.Bytes(), 0644)).To(Succeed())
+
+		result, err := mavenBuild.Build(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		verifier := result.Layers[0].(maven.Distribution).Verifier
+		Expect(verifier).NotTo(BeNil())
+		Expect(verifier.SignatureURI).To(Equal("https://localhost/apache-maven-bin.tar.gz.asc"))
+		Expect(verifier.KeyRing).To(HaveLen(1))
+	})
+
 	context("BP_MAVEN_DAEMON_ENABLED is true", func() {
 		it.Before(func() {
 			Expect(os.Setenv("BP_MAVEN_DAEMON_ENABLED", "TRUE")).To(Succeed())
@@ -342,61 +723,783 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			Expect(result.Layers[2].Name()).To(Equal("application"))
 			Expect(result.Layers[2].(libbs.Application).Command).To(Equal(filepath.Join(ctx.Layers.Path, "mvnd", "bin", "mvnd")))
 			Expect(result.Layers[2].(libbs.Application).Arguments).To(Equal([]string{"test-argument"}))
+			Expect(result.Layers[2].(libbs.Application).Executor).To(BeAssignableToTypeOf(maven.MvndStoppingExecutor{}))
 
 			Expect(result.BOM.Entries).To(HaveLen(1))
 			Expect(result.BOM.Entries[0].Name).To(Equal("mvnd"))
 			Expect(result.BOM.Entries[0].Build).To(BeTrue())
 			Expect(result.BOM.Entries[0].Launch).To(BeFalse())
 		})
-	})
 
-	context("does not contribute distribution if mvn on PATH", func() {
-		var addToPath string
-		var mvnFilePath string
+		it("falls back to the Maven distribution if mvnd is not available for the architecture", func() {
+			t.Setenv("BP_ARCH", "ppc64le")
+			t.Setenv("PATH", "/does-not-exist") // prevents mvn from possibly being on the PATH
+			ctx.Plan.Entries = append(ctx.Plan.Entries, libcnb.BuildpackPlanEntry{Name: "maven"})
 
-		it.Before(func() {
-			var err error
-			addToPath, err = os.MkdirTemp("", "add-to-path")
-			Expect(err).NotTo(HaveOccurred())
+			ctx.Buildpack.Metadata["dependencies"] = []map[string]interface{}{
+				{
+					"id":      "mvnd",
+					"version": "1.1.1",
+					"stacks":  []interface{}{"test-stack-id"},
+					"cpes":    []string{"cpe:2.3:a:apache:mvnd:0.7.1:*:*:*:*:*:*:*"},
+					"purl":    "pkg:generic/apache-mvnd@0.7.1?arch=amd64",
+				},
+				{
+					"id":      "maven",
+					"version": "1.1.1",
+					"stacks":  []interface{}{"test-stack-id"},
+					"cpes":    []string{"cpe:2.3:a:apache:maven:3.8.3:*:*:*:*:*:*:*"},
+					"purl":    "pkg:generic/apache-maven@3.8.3",
+				},
+			}
+			ctx.StackID = "test-stack-id"
 
-			t.Setenv("PATH", addToPath)
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
 
-			mvnFilePath = filepath.Join(addToPath, "mvn")
+			Expect(result.Layers).To(HaveLen(3))
+			Expect(result.Layers[0].Name()).To(Equal("maven"))
+			Expect(result.Layers[2].(libbs.Application).Command).To(Equal(filepath.Join(ctx.Layers.Path, "maven", "bin", "mvn")))
 		})
+	})
 
-		it.After(func() {
-			Expect(os.RemoveAll(addToPath)).To(Succeed())
+	context("BP_MAVEN_TIMING_ENABLED is true", func() {
+		it.Before(func() {
+			t.Setenv("BP_MAVEN_TIMING_ENABLED", "true")
+			t.Setenv("PATH", "/does-not-exist") // prevents mvn from possibly being on the PATH
 		})
 
-		it("contributes mvn on PATH", func() {
-			Expect(os.WriteFile(mvnFilePath, []byte{}, 0755)).ToNot(HaveOccurred())
+		it("times each build step and appends a timing report", func() {
+			ctx.Plan.Entries = append(ctx.Plan.Entries, libcnb.BuildpackPlanEntry{Name: "maven"})
+
+			ctx.Buildpack.Metadata["dependencies"] = []map[string]interface{}{
+				{
+					"id":      "maven",
+					"version": "1.1.1",
+					"stacks":  []interface{}{"test-stack-id"},
+					"cpes":    []string{"cpe:2.3:a:apache:maven:3.8.3:*:*:*:*:*:*:*"},
+					"purl":    "pkg:generic/apache-maven@3.8.3",
+				},
+			}
+			ctx.StackID = "test-stack-id"
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers).To(HaveLen(3))
+			Expect(result.Layers[0].Name()).To(Equal("maven"))
+			Expect(result.Layers[0].(maven.TimedLayerContributor).Phase).To(Equal(maven.PhaseMavenInstallation))
+			Expect(result.Layers[1].Name()).To(Equal("cache"))
+			Expect(result.Layers[1].(maven.TimedLayerContributor).Phase).To(Equal(maven.PhaseCacheSetup))
+			Expect(result.Layers[2].Name()).To(Equal("application"))
+			reporting := result.Layers[2].(maven.ReportingLayerContributor)
+			application := reporting.LayerContributor.(maven.TimedLayerContributor)
+			Expect(application.Remainder).To(Equal(maven.PhasePostBuild))
+			Expect(application.LayerContributor.(libbs.Application).Executor).To(BeAssignableToTypeOf(maven.TimedExecutor{}))
+
+			timer := application.Timer
+			Expect(reporting.Reports).To(HaveLen(1))
+			Expect(reporting.Reports[0].(maven.TimingReport).Timer).To(BeIdenticalTo(timer))
+			Expect(timer.Phases).To(HaveLen(1))
+			Expect(timer.Phases[0].Name).To(Equal(maven.PhaseMavenSelection))
+		})
 
+		it("passes the report path to the timing report", func() {
+			t.Setenv("BP_MAVEN_TIMING_REPORT_PATH", "/tmp/timings.json")
+			Expect(os.WriteFile(mvnwFilepath, []byte{}, 0644)).To(Succeed())
 			ctx.Plan.Entries = append(ctx.Plan.Entries, libcnb.BuildpackPlanEntry{Name: "maven"})
 
 			result, err := mavenBuild.Build(ctx)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(result.Layers).To(HaveLen(2))
-			Expect(result.Layers[0].Name()).To(Equal("cache"))
-			Expect(result.Layers[1].Name()).To(Equal("application"))
-			Expect(result.Layers[1].(libbs.Application).Command).To(Equal(mvnFilePath))
-			Expect(result.Layers[1].(libbs.Application).Arguments).To(Equal([]string{"test-argument"}))
+			Expect(result.Layers[1].(maven.ReportingLayerContributor).Reports[0].(maven.TimingReport).Path).To(Equal("/tmp/timings.json"))
 		})
 	})
 
-	context("maven is not in the buildplan nor on the path", func() {
+	context("BP_MAVEN_REPORT_PATH is set", func() {
 		it.Before(func() {
-			t.Setenv("PATH", "")
+			t.Setenv("BP_MAVEN_REPORT_PATH", "/tmp/report.json")
+			t.Setenv("BP_MAVEN_ACTIVE_PROFILES", "p1,!p2")
+			t.Setenv("PATH", "/does-not-exist") // prevents mvn from possibly being on the PATH
 		})
 
-		it("returns a meaningful error", func() {
-			_, err := mavenBuild.Build(ctx)
-			Expect(err).To(MatchError(ContainSubstring("unable to lookup 'mvn'")))
-		})
-	})
+		it("appends a build report describing the build", func() {
+			ctx.Plan.Entries = append(ctx.Plan.Entries, libcnb.BuildpackPlanEntry{Name: "maven"})
 
-	context("maven settings bindings exists", func() {
-		var result libcnb.BuildResult
+			ctx.Buildpack.Metadata["dependencies"] = []map[string]interface{}{
+				{
+					"id":      "maven",
+					"version": "1.1.1",
+					"stacks":  []interface{}{"test-stack-id"},
+					"cpes":    []string{"cpe:2.3:a:apache:maven:3.8.3:*:*:*:*:*:*:*"},
+					"purl":    "pkg:generic/apache-maven@3.8.3",
+				},
+			}
+			ctx.StackID = "test-stack-id"
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers).To(HaveLen(3))
+			reporting := result.Layers[2].(maven.ReportingLayerContributor)
+			Expect(reporting.LayerContributor.(libbs.Application).Executor).To(BeAssignableToTypeOf(maven.ReportingExecutor{}))
+			Expect(reporting.Reports).To(HaveLen(1))
+
+			report := reporting.Reports[0].(maven.BuildReport)
+			Expect(report.Path).To(Equal("/tmp/report.json"))
+			Expect(report.Summary.Manager).To(Equal("StandardMavenManager"))
+			Expect(report.Summary.Version).To(Equal("1.1.1"))
+			Expect(report.Summary.Command).To(Equal(filepath.Join(ctx.Layers.Path, "maven", "bin", "mvn")))
+			Expect(report.Summary.Arguments).To(Equal([]string{"test-argument", "-P", "p1,!p2"}))
+			Expect(report.Summary.ActiveProfiles).To(Equal([]string{"p1", "!p2"}))
+			Expect(report.Summary.Timings).To(BeNil())
+		})
+
+		it("includes the timings when enabled", func() {
+			t.Setenv("BP_MAVEN_TIMING_ENABLED", "true")
+			Expect(os.WriteFile(mvnwFilepath, []byte{}, 0644)).To(Succeed())
+			ctx.Plan.Entries = append(ctx.Plan.Entries, libcnb.BuildpackPlanEntry{Name: "maven"})
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers).To(HaveLen(2))
+			reporting := result.Layers[1].(maven.ReportingLayerContributor)
+			Expect(reporting.LayerContributor.Name()).To(Equal("application"))
+			report := reporting.Reports[0].(maven.BuildReport)
+			Expect(report.Summary.Manager).To(Equal("WrapperMavenManager"))
+			Expect(report.Summary.Timings).To(BeIdenticalTo(reporting.Reports[1].(maven.TimingReport).Timer))
+		})
+	})
+
+	context("BP_MAVEN_DRY_RUN is true", func() {
+		it.Before(func() {
+			t.Setenv("BP_MAVEN_DRY_RUN", "true")
+		})
+
+		it("explains the build without contributing layers", func() {
+			t.Setenv("BP_MAVEN_ACTIVE_PROFILES", "p1")
+			t.Setenv("BP_MAVEN_BUILT_ARTIFACT", "target/*.jar")
+			Expect(os.WriteFile(mvnwFilepath, []byte{}, 0644)).To(Succeed())
+			ctx.Plan.Entries = append(ctx.Plan.Entries, libcnb.BuildpackPlanEntry{Name: "maven"})
+
+			out := &bytes.Buffer{}
+			mavenBuild.Logger = bard.NewLogger(out)
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers).To(BeEmpty())
+			Expect(result.BOM.Entries).To(BeEmpty())
+			Expect(out.String()).To(ContainSubstring("Maven manager: WrapperMavenManager"))
+			Expect(out.String()).To(ContainSubstring(fmt.Sprintf("Command: %s test-argument -P p1", mvnwFilepath)))
+			Expect(out.String()).To(ContainSubstring(fmt.Sprintf("Working directory: %s", ctx.Application.Path)))
+			Expect(out.String()).To(ContainSubstring("Artifact pattern: target/*.jar"))
+			Expect(out.String()).To(ContainSubstring("Layers: cache, application"))
+		})
+
+		it("leaves the Maven Wrapper as it is", func() {
+			Expect(os.WriteFile(mvnwFilepath, []byte("#!/bin/sh\r\n"), 0644)).To(Succeed())
+			ctx.Plan.Entries = append(ctx.Plan.Entries, libcnb.BuildpackPlanEntry{Name: "maven"})
+
+			_, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.ReadFile(mvnwFilepath)).To(Equal([]byte("#!/bin/sh\r\n")))
+			s, err := os.Stat(mvnwFilepath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Mode().Perm()).To(Equal(os.FileMode(0644)))
+		})
+
+		it("keeps the layers of a previous build", func() {
+			Expect(os.WriteFile(mvnwFilepath, []byte{}, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Layers.Path, "cache.toml"), []byte("cache = true\n"), 0644)).To(Succeed())
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers).To(Equal([]libcnb.LayerContributor{maven.UnchangedLayer{LayerName: "cache"}}))
+
+			layer, err := ctx.Layers.Layer("cache")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Layers[0].Contribute(layer)).To(Equal(layer))
+		})
+
+		it("explains the environment Maven runs with", func() {
+			t.Setenv("PATH", "/does-not-exist") // prevents mvn from possibly being on the PATH
+			t.Setenv("BP_MAVEN_OPTS", "-Xmx1g")
+			ctx.Plan.Entries = append(ctx.Plan.Entries, libcnb.BuildpackPlanEntry{Name: "maven"})
+			ctx.Buildpack.Metadata["dependencies"] = []map[string]interface{}{
+				{
+					"id":      "maven",
+					"version": "1.1.1",
+					"stacks":  []interface{}{"test-stack-id"},
+				},
+			}
+			ctx.StackID = "test-stack-id"
+
+			out := &bytes.Buffer{}
+			mavenBuild.Logger = bard.NewLogger(out)
+
+			_, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(out.String()).To(ContainSubstring(fmt.Sprintf("MAVEN_HOME=%s", filepath.Join(ctx.Layers.Path, "maven"))))
+			Expect(out.String()).To(ContainSubstring("MAVEN_REPO_LOCAL="))
+			Expect(out.String()).To(ContainSubstring("MAVEN_OPTS=-Xmx1g"))
+		})
+
+		context("the root POM aggregates projects with the 4.1.0 model", func() {
+			it.Before(func() {
+				ctx.Buildpack.Metadata = map[string]interface{}{
+					"configurations": []map[string]interface{}{
+						{"name": "BP_MAVEN_BUILD_ARGUMENTS", "default": "test-argument"},
+						{"name": "BP_MAVEN_BUILT_ARTIFACT", "default": "target/*.[ejw]ar"},
+					},
+				}
+				Expect(os.WriteFile(mvnwFilepath, []byte{}, 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte(`<project root="true">
+  <modelVersion>4.1.0</modelVersion>
+  <packaging>pom</packaging>
+</project>`), 0644)).To(Succeed())
+				Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "app"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "app", "pom.xml"), []byte(`<project>
+  <modelVersion>4.1.0</modelVersion>
+  <parent/>
+  <packaging>war</packaging>
+</project>`), 0644)).To(Succeed())
+				Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "bom"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "bom", "pom.xml"), []byte(`<project>
+  <modelVersion>4.1.0</modelVersion>
+  <packaging>pom</packaging>
+</project>`), 0644)).To(Succeed())
+			})
+
+			it("looks for the artifact in the only project that packages something", func() {
+				out := &bytes.Buffer{}
+				mavenBuild.Logger = bard.NewLogger(out)
+
+				_, err := mavenBuild.Build(ctx)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(out.String()).To(ContainSubstring("Artifact pattern: app/target/*.[ejw]ar"))
+			})
+
+			it("uses the configured module", func() {
+				t.Setenv("BP_MAVEN_BUILT_MODULE", "bom")
+				out := &bytes.Buffer{}
+				mavenBuild.Logger = bard.NewLogger(out)
+
+				_, err := mavenBuild.Build(ctx)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(out.String()).To(ContainSubstring("Artifact pattern: bom/target/*.[ejw]ar"))
+			})
+		})
+	})
+
+	context("container resources", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(mvnwFilepath, []byte{}, 0644)).To(Succeed())
+		})
+
+		it("adds the configured thread count", func() {
+			t.Setenv("BP_MAVEN_THREADS", "4")
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[1].(libbs.Application).Arguments).To(Equal([]string{"-T", "4", "test-argument"}))
+		})
+
+		it("does not add a thread count if .mvn/maven.config sets one", func() {
+			t.Setenv("BP_MAVEN_THREADS", "4")
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, ".mvn"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, ".mvn", "maven.config"), []byte("-T 2C\n"), 0644)).To(Succeed())
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[1].(libbs.Application).Arguments).To(Equal([]string{"test-argument"}))
+		})
+
+		it("does not add a thread count if the build arguments set one", func() {
+			t.Setenv("BP_MAVEN_THREADS", "4")
+			t.Setenv("BP_MAVEN_BUILD_ARGUMENTS", "--threads=2 package")
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[1].(libbs.Application).Arguments).To(Equal([]string{"--threads=2", "package"}))
+		})
+
+		it("runs Maven with the configured MAVEN_OPTS", func() {
+			t.Setenv("BP_MAVEN_OPTS", "-Xmx1g")
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[1].(libbs.Application).Executor).To(Equal(maven.EnvironmentExecutor{
+				Environment: map[string]string{"MAVEN_OPTS": "-Xmx1g"},
+			}))
+		})
+	})
+
+	context("BP_MAVEN_BUILD_CACHE_ENABLED is true", func() {
+		it.Before(func() {
+			t.Setenv("BP_MAVEN_BUILD_CACHE_ENABLED", "true")
+			t.Setenv("BP_MAVEN_BUILD_CACHE_VERSION", "1.2.0")
+			Expect(os.WriteFile(mvnwFilepath, []byte{}, 0644)).To(Succeed())
+		})
+
+		it("configures the build cache extension with a cache layer", func() {
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers).To(HaveLen(3))
+			Expect(result.Layers[0].Name()).To(Equal("cache"))
+			Expect(result.Layers[1].Name()).To(Equal("build-cache"))
+			Expect(result.Layers[2].(libbs.Application).Arguments).To(Equal([]string{
+				"test-argument",
+				"-Dmaven.build.cache.enabled=true",
+				fmt.Sprintf("-Dmaven.build.cache.location=%s", filepath.Join(ctx.Layers.Path, "build-cache")),
+			}))
+
+			b, err := os.ReadFile(filepath.Join(ctx.Application.Path, ".mvn", "extensions.xml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(ContainSubstring("<artifactId>maven-build-cache-extension</artifactId>"))
+			Expect(string(b)).To(ContainSubstring("<version>1.2.0</version>"))
+		})
+
+		it("does not rewrite the project extensions when they declare the extension", func() {
+			content := `<extensions>
+  <extension>
+    <groupId>org.apache.maven.extensions</groupId>
+    <artifactId>maven-build-cache-extension</artifactId>
+    <version>1.1.0</version>
+  </extension>
+</extensions>`
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, ".mvn"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, ".mvn", "extensions.xml"), []byte(content), 0644)).To(Succeed())
+
+			_, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.ReadFile(filepath.Join(ctx.Application.Path, ".mvn", "extensions.xml"))).To(Equal([]byte(content)))
+		})
+
+		it("does not write the project extensions in a dry run", func() {
+			t.Setenv("BP_MAVEN_DRY_RUN", "true")
+
+			_, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(filepath.Join(ctx.Application.Path, ".mvn", "extensions.xml")).NotTo(BeAnExistingFile())
+		})
+	})
+
+	context("BP_MAVEN_TARGET_CACHE_ENABLED is true", func() {
+		it.Before(func() {
+			t.Setenv("BP_MAVEN_TARGET_CACHE_ENABLED", "true")
+			t.Setenv("JAVA_HOME", t.TempDir())
+			Expect(os.WriteFile(filepath.Join(os.Getenv("JAVA_HOME"), "release"), []byte(`JAVA_VERSION="17.0.1"`), 0644)).To(Succeed())
+			Expect(os.WriteFile(mvnwFilepath, []byte{}, 0644)).To(Succeed())
+		})
+
+		it("restores target directories and saves them after Maven runs", func() {
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers).To(HaveLen(3))
+			Expect(result.Layers[0].Name()).To(Equal("cache"))
+			Expect(result.Layers[1].Name()).To(Equal("target-cache"))
+			Expect(result.Layers[1].(maven.TargetCache).LayerContributor.ExpectedMetadata).To(HaveKeyWithValue("jdk-version", "17.0.1"))
+			Expect(result.Layers[2].(libbs.Application).Executor).To(Equal(maven.TargetCachingExecutor{
+				ApplicationPath: ctx.Application.Path,
+				LayerPath:       filepath.Join(ctx.Layers.Path, "target-cache"),
+				Logger:          mavenBuild.Logger,
+			}))
+		})
+
+		it("does not cache target directories when the JDK version is unknown", func() {
+			Expect(os.Remove(filepath.Join(os.Getenv("JAVA_HOME"), "release"))).To(Succeed())
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers).To(HaveLen(2))
+			Expect(result.Layers[0].Name()).To(Equal("cache"))
+			Expect(result.Layers[1].(libbs.Application).Executor).To(BeNil())
+		})
+	})
+
+	context("there is a Polyglot Maven POM", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(mvnwFilepath, []byte{}, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.kts"), []byte{}, 0644)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, ".mvn"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, ".mvn", "extensions.xml"), []byte(`<extensions>
+  <extension>
+    <groupId>io.takari.polyglot</groupId>
+    <artifactId>polyglot-kotlin</artifactId>
+    <version>0.7.0</version>
+  </extension>
+</extensions>`), 0644)).To(Succeed())
+		})
+
+		it("runs Maven with the polyglot POM", func() {
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[1].(libbs.Application).Arguments).To(Equal([]string{"--file", "pom.kts", "test-argument"}))
+		})
+
+		it("prefers pom.xml", func() {
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte{}, 0644)).To(Succeed())
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[1].(libbs.Application).Arguments).To(Equal([]string{"test-argument"}))
+		})
+	})
+
+	it("builds a native image when BP_NATIVE_IMAGE is set", func() {
+		t.Setenv("BP_NATIVE_IMAGE", "true")
+		Expect(os.WriteFile(mvnwFilepath, []byte{}, 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte(`<project>
+  <build>
+    <plugins>
+      <plugin>
+        <groupId>org.graalvm.buildtools</groupId>
+        <artifactId>native-maven-plugin</artifactId>
+      </plugin>
+    </plugins>
+  </build>
+</project>`), 0644)).To(Succeed())
+
+		result, err := mavenBuild.Build(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		application := result.Layers[1].(libbs.Application)
+		Expect(application.Arguments).To(Equal([]string{"test-argument", "native:compile-no-fork"}))
+		Expect(application.ArtifactResolver.Pattern()).To(Equal("target/native-image.zip"))
+	})
+
+	it("leaves native images to the native-image buildpack when BP_MAVEN_NATIVE_IMAGE is false", func() {
+		t.Setenv("BP_NATIVE_IMAGE", "true")
+		t.Setenv("BP_MAVEN_NATIVE_IMAGE", "false")
+		Expect(os.WriteFile(mvnwFilepath, []byte{}, 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte(`<project>
+  <build>
+    <plugins>
+      <plugin>
+        <groupId>org.graalvm.buildtools</groupId>
+        <artifactId>native-maven-plugin</artifactId>
+      </plugin>
+    </plugins>
+  </build>
+</project>`), 0644)).To(Succeed())
+
+		result, err := mavenBuild.Build(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		application := result.Layers[1].(libbs.Application)
+		Expect(application.Arguments).To(Equal([]string{"test-argument"}))
+		Expect(application.Executor).To(BeNil())
+	})
+
+	context("BP_MAVEN_NATIVE_IMAGE is true", func() {
+		it.Before(func() {
+			t.Setenv("BP_MAVEN_NATIVE_IMAGE", "true")
+			Expect(os.WriteFile(mvnwFilepath, []byte{}, 0644)).To(Succeed())
+		})
+
+		it("activates the native profile and archives the native executable", func() {
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte(`<project>
+  <profiles>
+    <profile>
+      <id>native</id>
+    </profile>
+  </profiles>
+</project>`), 0644)).To(Succeed())
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			application := result.Layers[1].(libbs.Application)
+			Expect(application.Arguments).To(Equal([]string{"test-argument", "-Pnative"}))
+			Expect(application.ArtifactResolver.Pattern()).To(Equal("target/native-image.zip"))
+			Expect(application.Executor).To(Equal(maven.NativeArchivingExecutor{
+				Directory: filepath.Join(ctx.Application.Path, "target"),
+				Logger:    mavenBuild.Logger,
+			}))
+		})
+
+		it("runs the native goal if there is no native profile", func() {
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte(`<project>
+  <build>
+    <plugins>
+      <plugin>
+        <groupId>org.graalvm.buildtools</groupId>
+        <artifactId>native-maven-plugin</artifactId>
+      </plugin>
+    </plugins>
+  </build>
+</project>`), 0644)).To(Succeed())
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[1].(libbs.Application).Arguments).To(Equal([]string{"test-argument", "native:compile-no-fork"}))
+		})
+
+		it("uses the configured artifact", func() {
+			t.Setenv("BP_MAVEN_BUILT_ARTIFACT", "target/app")
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte(`<project>
+  <profiles>
+    <profile>
+      <id>native</id>
+    </profile>
+  </profiles>
+</project>`), 0644)).To(Succeed())
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			application := result.Layers[1].(libbs.Application)
+			Expect(application.ArtifactResolver.Pattern()).To(Equal("target/app"))
+			Expect(application.Executor).To(BeNil())
+		})
+
+		it("builds the application if the project does not build a native image", func() {
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte(`<project/>`), 0644)).To(Succeed())
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			application := result.Layers[1].(libbs.Application)
+			Expect(application.Arguments).To(Equal([]string{"test-argument"}))
+			Expect(application.Executor).To(BeNil())
+		})
+	})
+
+	context("the POM runs a frontend build", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(mvnwFilepath, []byte{}, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte(`<project>
+  <build>
+    <plugins>
+      <plugin>
+        <groupId>com.github.eirslett</groupId>
+        <artifactId>frontend-maven-plugin</artifactId>
+        <executions>
+          <execution>
+            <goals>
+              <goal>install-node-and-pnpm</goal>
+              <goal>pnpm</goal>
+            </goals>
+          </execution>
+        </executions>
+      </plugin>
+    </plugins>
+  </build>
+</project>`), 0644)).To(Succeed())
+		})
+
+		it("skips the installation of node when it is installed by another buildpack", func() {
+			t.Setenv("BP_JAVA_INSTALL_NODE", "true")
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[1].(libbs.Application).Arguments).To(Equal([]string{"test-argument", "-Dskip.installnodepnpm"}))
+		})
+
+		it("does not skip the installation of node unless BP_JAVA_INSTALL_NODE is set", func() {
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[1].(libbs.Application).Arguments).To(Equal([]string{"test-argument"}))
+		})
+	})
+
+	context("the POM builds a Quarkus application", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(mvnwFilepath, []byte{}, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte(`<project>
+  <build>
+    <plugins>
+      <plugin>
+        <groupId>io.quarkus.platform</groupId>
+        <artifactId>quarkus-maven-plugin</artifactId>
+      </plugin>
+    </plugins>
+  </build>
+</project>`), 0644)).To(Succeed())
+		})
+
+		it("looks for the fast-jar by default", func() {
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			application := result.Layers[1].(libbs.Application)
+			Expect(application.ArtifactResolver.Pattern()).To(Equal(
+				"target/quarkus-app/lib target/quarkus-app/*.jar target/quarkus-app/app target/quarkus-app/quarkus"))
+		})
+
+		it("looks for the uber-jar configured in application.properties", func() {
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "src", "main", "resources"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "src", "main", "resources", "application.properties"),
+				[]byte("quarkus.package.jar.type=uber-jar\n"), 0644)).To(Succeed())
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			application := result.Layers[1].(libbs.Application)
+			Expect(application.ArtifactResolver.Pattern()).To(Equal("target/*-runner.jar"))
+		})
+
+		it("looks for the legacy-jar in the configured module", func() {
+			t.Setenv("BP_MAVEN_BUILT_MODULE", "app")
+			t.Setenv("BP_MAVEN_BUILD_ARGUMENTS", "-Dquarkus.package.type=legacy-jar package")
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "app"), 0755)).To(Succeed())
+			Expect(os.Rename(filepath.Join(ctx.Application.Path, "pom.xml"), filepath.Join(ctx.Application.Path, "app", "pom.xml"))).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte(`<project/>`), 0644)).To(Succeed())
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			application := result.Layers[1].(libbs.Application)
+			Expect(application.ArtifactResolver.Pattern()).To(Equal("app/target/*-runner.jar app/target/lib"))
+		})
+	})
+
+	context("the POM builds a Spring Boot application", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(mvnwFilepath, []byte{}, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte(`<project>
+  <parent>
+    <groupId>org.springframework.boot</groupId>
+    <artifactId>spring-boot-starter-parent</artifactId>
+    <version>3.3.0</version>
+  </parent>
+  <artifactId>demo</artifactId>
+  <version>1.0.0</version>
+</project>`), 0644)).To(Succeed())
+		})
+
+		it("looks for the repackaged archive", func() {
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			application := result.Layers[1].(libbs.Application)
+			Expect(application.ArtifactResolver.Pattern()).To(Equal("target/demo-1.0.0.jar"))
+			Expect(application.Arguments).To(Equal([]string{"test-argument"}))
+			Expect(application.Executor).To(BeNil())
+		})
+
+		it("generates the build information", func() {
+			t.Setenv("BP_MAVEN_SPRING_BOOT_BUILD_INFO", "true")
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[1].(libbs.Application).Arguments).To(Equal([]string{"spring-boot:build-info", "test-argument"}))
+		})
+
+		it("generates the build information after clean", func() {
+			t.Setenv("BP_MAVEN_SPRING_BOOT_BUILD_INFO", "true")
+			t.Setenv("BP_MAVEN_BUILD_ARGUMENTS", "clean package")
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[1].(libbs.Application).Arguments).To(Equal([]string{"clean", "spring-boot:build-info", "package"}))
+		})
+
+		it("uses the configured artifact", func() {
+			t.Setenv("BP_MAVEN_BUILT_ARTIFACT", "target/*.jar")
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			application := result.Layers[1].(libbs.Application)
+			Expect(application.ArtifactResolver.Pattern()).To(Equal("target/*.jar"))
+			Expect(application.Executor).To(BeNil())
+		})
+
+		it("removes the secondary archives when the name of the repackaged archive cannot be resolved", func() {
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte(`<project>
+  <parent>
+    <groupId>org.springframework.boot</groupId>
+    <artifactId>spring-boot-starter-parent</artifactId>
+    <version>3.3.0</version>
+  </parent>
+  <artifactId>demo</artifactId>
+  <version>${revision}</version>
+</project>`), 0644)).To(Succeed())
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			application := result.Layers[1].(libbs.Application)
+			Expect(application.ArtifactResolver.Pattern()).To(Equal("target/*.jar"))
+			Expect(application.Executor).To(Equal(maven.SecondaryArchiveRemovingExecutor{
+				Logger:  mavenBuild.Logger,
+				Pattern: filepath.Join(ctx.Application.Path, "target", "*.jar"),
+			}))
+		})
+	})
+
+	context("does not contribute distribution if mvn on PATH", func() {
+		var addToPath string
+		var mvnFilePath string
+
+		it.Before(func() {
+			var err error
+			addToPath, err = os.MkdirTemp("", "add-to-path")
+			Expect(err).NotTo(HaveOccurred())
+
+			t.Setenv("PATH", addToPath)
+
+			mvnFilePath = filepath.Join(addToPath, "mvn")
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(addToPath)).To(Succeed())
+		})
+
+		it("contributes mvn on PATH", func() {
+			Expect(os.WriteFile(mvnFilePath, []byte{}, 0755)).ToNot(HaveOccurred())
+
+			ctx.Plan.Entries = append(ctx.Plan.Entries, libcnb.BuildpackPlanEntry{Name: "maven"})
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers).To(HaveLen(2))
+			Expect(result.Layers[0].Name()).To(Equal("cache"))
+			Expect(result.Layers[1].Name()).To(Equal("application"))
+			Expect(result.Layers[1].(libbs.Application).Command).To(Equal(mvnFilePath))
+			Expect(result.Layers[1].(libbs.Application).Arguments).To(Equal([]string{"test-argument"}))
+		})
+	})
+
+	context("maven is not in the buildplan nor on the path", func() {
+		it.Before(func() {
+			t.Setenv("PATH", "")
+		})
+
+		it("returns a meaningful error", func() {
+			_, err := mavenBuild.Build(ctx)
+			Expect(err).To(MatchError(ContainSubstring("unable to lookup 'mvn'")))
+		})
+	})
+
+	context("maven settings bindings exists", func() {
+		var result libcnb.BuildResult
 
 		it.Before(func() {
 			var err error
@@ -446,6 +1549,18 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			Expect(mdMap["settings-sha256"]).To(Equal(expected))
 		})
 
+		it("prefers the settings of the build arguments", func() {
+			t.Setenv("BP_MAVEN_BUILD_ARGUMENTS", "--settings=/workspace/settings.xml")
+			out := &bytes.Buffer{}
+			mavenBuild.Logger = bard.NewLogger(out)
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[1].(libbs.Application).Arguments).To(Equal([]string{"--settings=/workspace/settings.xml"}))
+			Expect(out.String()).To(ContainSubstring("WARNING: the settings.xml of the maven binding is not used"))
+		})
+
 		context("BP_MAVEN_SETTINGS_PATH env var is set", func() {
 			it.Before(func() {
 				Expect(os.Setenv("BP_MAVEN_SETTINGS_PATH", "/workspace/settings.xml")).To(Succeed())
@@ -469,6 +1584,92 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 
+	context("maven extensions binding exists", func() {
+		it.Before(func() {
+			var err error
+			ctx.Platform.Path, err = os.MkdirTemp("", "maven-test-platform")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.WriteFile(mvnwFilepath, []byte{}, 0644)).To(Succeed())
+			ctx.Platform.Bindings = libcnb.Bindings{
+				{
+					Name:   "some-extensions",
+					Type:   "maven-extensions",
+					Secret: map[string]string{"extensions.xml": ""},
+					Path:   filepath.Join(ctx.Platform.Path, "bindings", "some-extensions"),
+				},
+			}
+			extensionsPath, ok := ctx.Platform.Bindings[0].SecretFilePath("extensions.xml")
+			Expect(ok).To(BeTrue())
+			Expect(os.MkdirAll(filepath.Dir(extensionsPath), 0777)).To(Succeed())
+			Expect(os.WriteFile(extensionsPath, []byte(`<extensions>
+  <extension>
+    <groupId>com.example</groupId>
+    <artifactId>custom-wagon</artifactId>
+    <version>2.0.0</version>
+  </extension>
+</extensions>`), 0644)).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(ctx.Platform.Path)).To(Succeed())
+		})
+
+		it("merges the extensions into the project and the layer metadata", func() {
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			b, err := os.ReadFile(filepath.Join(ctx.Application.Path, ".mvn", "extensions.xml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(ContainSubstring("<artifactId>custom-wagon</artifactId>"))
+
+			md := result.Layers[1].(libbs.Application).LayerContributor.ExpectedMetadata.(map[string]interface{})
+			Expect(md).To(HaveKey("extensions-sha256"))
+		})
+
+		it("adds the build cache extension with them and records the digest of the result", func() {
+			t.Setenv("BP_MAVEN_BUILD_CACHE_ENABLED", "true")
+			t.Setenv("BP_MAVEN_BUILD_CACHE_VERSION", "1.2.0")
+
+			result, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			b, err := os.ReadFile(filepath.Join(ctx.Application.Path, ".mvn", "extensions.xml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(ContainSubstring("<artifactId>custom-wagon</artifactId>"))
+			Expect(string(b)).To(ContainSubstring("<artifactId>maven-build-cache-extension</artifactId>"))
+
+			sum := sha256.Sum256(b)
+			md := result.Layers[2].(libbs.Application).LayerContributor.ExpectedMetadata.(map[string]interface{})
+			Expect(md).To(HaveKeyWithValue("extensions-sha256", hex.EncodeToString(sum[:])))
+		})
+
+		it("does not rewrite the project extensions when they declare every extension", func() {
+			content := `<extensions>
+  <extension>
+    <groupId>com.example</groupId>
+    <artifactId>custom-wagon</artifactId>
+    <version>1.0.0</version>
+  </extension>
+</extensions>`
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, ".mvn"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, ".mvn", "extensions.xml"), []byte(content), 0644)).To(Succeed())
+
+			_, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.ReadFile(filepath.Join(ctx.Application.Path, ".mvn", "extensions.xml"))).To(Equal([]byte(content)))
+		})
+
+		it("does not write the project extensions in a dry run", func() {
+			t.Setenv("BP_MAVEN_DRY_RUN", "true")
+
+			_, err := mavenBuild.Build(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(filepath.Join(ctx.Application.Path, ".mvn", "extensions.xml")).NotTo(BeAnExistingFile())
+		})
+	})
+
 	context("maven settings incl. settings-security bindings exists", func() {
 		var result libcnb.BuildResult
 
@@ -543,7 +1744,7 @@ type FakeApplicationFactory struct{}
 func (f *FakeApplicationFactory) NewApplication(
 	additionalMetdata map[string]interface{},
 	argugments []string,
-	_ libbs.ArtifactResolver,
+	artifactResolver libbs.ArtifactResolver,
 	_ libbs.Cache,
 	command string,
 	_ *libcnb.BOM,
@@ -558,6 +1759,7 @@ func (f *FakeApplicationFactory) NewApplication(
 	return libbs.Application{
 		LayerContributor: contributor,
 		Arguments:        argugments,
+		ArtifactResolver: artifactResolver,
 		Command:          command,
 	}, nil
 }
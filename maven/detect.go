@@ -34,14 +34,25 @@ const (
 	PlanEntrySyft                  = "syft"
 	PlanEntryYarn				   = "yarn"
 	PlanEntryNode				   = "node"
+	PlanEntryPnpm                  = "pnpm"
+	PlanEntryNativeImageBuilder    = "native-image-builder"
+
+	// PlanMetadataPOMFile is the metadata of the maven requirement with the POM found by detection
+	PlanMetadataPOMFile = "pom-file"
 )
 
-type Detect struct{}
+// Detect explains its decisions with debug logging, enabled with $BP_LOG_LEVEL=DEBUG, so that failed detection can be
+// diagnosed
+type Detect struct {
+	Logger bard.Logger
+}
 
-func (Detect) Detect(context libcnb.DetectContext) (libcnb.DetectResult, error) {
+func (d Detect) Detect(context libcnb.DetectContext) (libcnb.DetectResult, error) {
 	// if MANIFEST.MF exists, we have a WAR/JAR so we don't build
-	_, err := os.Stat(filepath.Join(context.Application.Path, "META-INF", "MANIFEST.MF"))
+	manifest := filepath.Join(context.Application.Path, "META-INF", "MANIFEST.MF")
+	_, err := os.Stat(manifest)
 	if err == nil {
+		d.Logger.Debugf("Found %s, the application is already built, failing detection", manifest)
 		return libcnb.DetectResult{}, nil
 	}
 
@@ -73,12 +84,44 @@ func (Detect) Detect(context libcnb.DetectContext) (libcnb.DetectResult, error)
 		},
 	}
 
-	pomFile, _ := cr.Resolve("BP_MAVEN_POM_FILE")
-	var performBuild bool
-	file := filepath.Join(context.Application.Path, pomFile)
+	projectPath, projectSet, err := ProjectPath(context.Application.Path, cr)
+	if err != nil {
+		d.Logger.Debugf("Unable to use the configured project path, only offering to provide Maven: %s", err)
+		return result, nil
+	}
+
+	pomFile, userSet := cr.Resolve("BP_MAVEN_POM_FILE")
+	var (
+		performBuild bool
+		nestedPOM    string
+	)
+	file := filepath.Join(projectPath, pomFile)
 	if _, err = os.Stat(file); err != nil && !os.IsNotExist(err) {
 		return libcnb.DetectResult{}, fmt.Errorf("unable to determine if %s exists\n%w", file, err)
-	} else if err == nil {
+	} else if os.IsNotExist(err) && !userSet {
+		d.Logger.Debugf("Unable to find POM %s, looking for a Polyglot Maven POM", file)
+		// fall back to a Polyglot Maven POM
+		polyglot, ok, perr := FindPolyglotPOM(projectPath)
+		if perr != nil {
+			d.Logger.Debugf("Unable to look for a Polyglot Maven POM: %s", perr)
+		}
+		if ok {
+			d.Logger.Debugf("Found Polyglot Maven POM %s", polyglot)
+			file, err = filepath.Join(projectPath, polyglot), nil
+		} else if !projectSet {
+			// fall back to a single root POM in a subdirectory
+			if nested, nerr := FindRootPOMs(context.Application.Path, NestedPOMSearchDepth); nerr != nil {
+				d.Logger.Debugf("Unable to look for nested POMs, only offering to provide Maven: %s", nerr)
+			} else if len(nested) == 1 {
+				d.Logger.Debugf("Found nested POM %s", nested[0])
+				nestedPOM, file, err = nested[0], filepath.Join(context.Application.Path, nested[0]), nil
+			} else if len(nested) > 1 {
+				d.Logger.Debugf("Found several nested POMs %s, set $BP_MAVEN_PROJECT_PATH to the project to build", strings.Join(nested, ", "))
+			}
+		}
+	}
+	if err == nil {
+		d.Logger.Debugf("Found POM %s, offering to build the application", file)
 		performBuild = true
 
 		// buildplan entry to support build-only
@@ -96,45 +139,69 @@ func (Detect) Detect(context libcnb.DetectContext) (libcnb.DetectResult, error)
 				{Name: PlanEntryMaven},
 			}
 		}
+		// the build uses the POM found by detection, unless it is configured
+		if nestedPOM != "" {
+			requireMetadata(result.Plans[1:], PlanEntryMaven, PlanMetadataPOMFile, nestedPOM)
+		}
+	}
+	if !performBuild {
+		d.Logger.Debugf("Unable to find POM %s, only offering to provide Maven; set $BP_MAVEN_POM_FILE if the POM is elsewhere", file)
 	}
+
 	// Only require Node if we will perform the build
 	if performBuild {
-		if cr.ResolveBool("BP_JAVA_INSTALL_NODE") {
-			var fileFound bool
-			files := []string{filepath.Join(context.Application.Path, "yarn.lock"), filepath.Join(context.Application.Path,"package.json")}
-			if customNodePath, _ := cr.Resolve("BP_NODE_PROJECT_PATH"); customNodePath != "" {
-				files = []string{filepath.Join(context.Application.Path, customNodePath, "yarn.lock"), filepath.Join(context.Application.Path, customNodePath, "package.json")}
+		if !cr.ResolveBool("BP_JAVA_INSTALL_NODE") {
+			d.Logger.Debugf("Not requiring Node, $BP_JAVA_INSTALL_NODE is not set")
+		} else {
+			var found bool
+			for _, path := range NodeProjectPaths(cr) {
+				dir := filepath.Join(context.Application.Path, path)
+				project, ok, err := ReadNodeProject(dir)
+				if err != nil {
+					return libcnb.DetectResult{}, err
+				} else if !ok {
+					d.Logger.Debugf("Unable to find a JavaScript project in %s", dir)
+					continue
+				}
+				found = true
+				d.Logger.Debugf("Found a %s project in %s", project.PackageManager, project.Directory)
+
+				// the first project to configure a version decides it, a project without one does not
+				for _, r := range project.Requires() {
+					addRequire(result.Plans[1:], r)
+				}
 			}
-			if err := findFile(files, func (file string) bool{
-				if strings.Contains(file,"yarn.lock") {
-					for i := 1; i < len(result.Plans); i++ {
-						result.Plans[i].Requires = []libcnb.BuildPlanRequire{
-							{Name: PlanEntrySyft},
-							{Name: PlanEntryJDK},
-							{Name: PlanEntryMaven},
-							{Name: PlanEntryYarn, Metadata: map[string]interface{}{"build": true}},
-							{Name: PlanEntryNode, Metadata: map[string]interface{}{"build": true}},
-						}
-					}
-					fileFound = true
-					return true
-				} else if strings.Contains(file,"package.json") {
-					for i := 1; i < len(result.Plans); i++ {
-						result.Plans[i].Requires = []libcnb.BuildPlanRequire{
-							{Name: PlanEntrySyft},
-							{Name: PlanEntryJDK},
-							{Name: PlanEntryMaven},
-							{Name: PlanEntryNode, Metadata: map[string]interface{}{"build": true}},
-						}
-					}
-					fileFound = true
+			// frontend builds run by Maven plugins use the Node installed by another buildpack
+			executions := FrontendExecutions(ReadReactor(file))
+			for _, e := range executions {
+				d.Logger.Debugf("Found a %s build run by Maven in %s", e.PackageManager, e.Directory)
+				addRequire(result.Plans[1:], libcnb.BuildPlanRequire{Name: PlanEntryNode, Metadata: map[string]interface{}{"build": true}})
+				switch e.PackageManager {
+				case PackageManagerYarn:
+					addRequire(result.Plans[1:], libcnb.BuildPlanRequire{Name: PlanEntryYarn, Metadata: map[string]interface{}{"build": true}})
+				case PackageManagerPNPM:
+					addRequire(result.Plans[1:], libcnb.BuildPlanRequire{Name: PlanEntryPnpm, Metadata: map[string]interface{}{"build": true}})
 				}
-				return false
-			}); err != nil{
-				return libcnb.DetectResult{}, err
 			}
-			if !fileFound{
-				l.Infof("unable to find a yarn.lock or package.json file, you may need to set BP_NODE_PROJECT_PATH")
+			if !found && len(executions) == 0 {
+				d.Logger.Debugf("Unable to find a package.json, package-lock.json, yarn.lock or pnpm-lock.yaml file, you may need to set BP_NODE_PROJECT_PATHS")
+			}
+		}
+		if pom, err := ReadPOM(file); err == nil {
+			// Only require a native image builder if the project is configured to build one
+			if NativeImageRequested(cr) && pom.IsNative() {
+				d.Logger.Debugf("Requiring a native image builder, the POM builds a native image")
+				for i := 1; i < len(result.Plans); i++ {
+					result.Plans[i].Requires = append(result.Plans[i].Requires, libcnb.BuildPlanRequire{Name: PlanEntryNativeImageBuilder})
+				}
+			}
+			if pom.IsSpringBoot() {
+				d.Logger.Debugf("The POM builds a Spring Boot application")
+				requireMetadata(result.Plans[1:], PlanEntryMaven, "spring-boot", true)
+			}
+			if pom.IsQuarkus() {
+				d.Logger.Debugf("The POM builds a Quarkus application")
+				requireMetadata(result.Plans[1:], PlanEntryMaven, "quarkus", true)
 			}
 		}
 		return result, nil
@@ -143,17 +210,55 @@ func (Detect) Detect(context libcnb.DetectContext) (libcnb.DetectResult, error)
 	return result, nil
 }
 
-func findFile (files []string, runWhenFound func(fileFound string) bool) error {
-	for _, file := range files {
-		_, err := os.Stat(file)
-		if os.IsNotExist(err) {
+// addRequire adds a requirement to every plan that does not already have one with its name.  A plan that already has
+// one keeps its metadata, adding the metadata it does not have, e.g. a version.
+func addRequire(plans []libcnb.BuildPlan, require libcnb.BuildPlanRequire) {
+	for i := range plans {
+		found := false
+		for j, r := range plans[i].Requires {
+			if r.Name == require.Name {
+				found = true
+				plans[i].Requires[j].Metadata = mergeMetadata(r.Metadata, require.Metadata)
+				break
+			}
+		}
+		if !found {
+			plans[i].Requires = append(plans[i].Requires, require)
+		}
+	}
+}
+
+// mergeMetadata returns the metadata with the keys of other it does not have.  A version is only taken from other
+// together with its source.
+func mergeMetadata(metadata map[string]interface{}, other map[string]interface{}) map[string]interface{} {
+	if len(other) == 0 {
+		return metadata
+	}
+
+	merged := map[string]interface{}{}
+	for k, v := range other {
+		if _, ok := metadata["version"]; ok && (k == "version" || k == "version-source") {
 			continue
-		} else if err != nil {
-			return fmt.Errorf("unable to determine if file %s exists \n%w", file, err)
 		}
-		if runWhenFound(file) {
-			break
+		merged[k] = v
+	}
+	for k, v := range metadata {
+		merged[k] = v
+	}
+	return merged
+}
+
+// requireMetadata adds metadata to the requirement of every plan with the given name
+func requireMetadata(plans []libcnb.BuildPlan, name string, key string, value interface{}) {
+	for i := range plans {
+		for j := range plans[i].Requires {
+			if plans[i].Requires[j].Name != name {
+				continue
+			}
+			if plans[i].Requires[j].Metadata == nil {
+				plans[i].Requires[j].Metadata = map[string]interface{}{}
+			}
+			plans[i].Requires[j].Metadata[key] = value
 		}
 	}
-	return nil
-}
\ No newline at end of file
+}
@@ -17,12 +17,14 @@
 package maven_test
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/buildpacks/libcnb"
 	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
 	"github.com/sclevine/spec"
 
 	"github.com/paketo-buildpacks/maven/v6/maven"
@@ -415,4 +417,442 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 			},
 		}))
 	})
+
+	context("there is a Polyglot Maven POM", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.yaml"), []byte{}, 0644)).To(Succeed())
+		})
+
+		it("passes when the polyglot extension is declared", func() {
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, ".mvn"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, ".mvn", "extensions.xml"), []byte(`<extensions>
+  <extension>
+    <groupId>io.takari.polyglot</groupId>
+    <artifactId>polyglot-yaml</artifactId>
+    <version>0.7.0</version>
+  </extension>
+</extensions>`), 0644)).To(Succeed())
+
+			result, err := detect.Detect(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Pass).To(BeTrue())
+			Expect(result.Plans).To(HaveLen(3))
+			Expect(result.Plans[2].Provides).To(Equal([]libcnb.BuildPlanProvide{{Name: "jvm-application-package"}}))
+		})
+
+		it("only provides when the polyglot extension is not declared", func() {
+			result, err := detect.Detect(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Pass).To(BeTrue())
+			Expect(result.Plans).To(HaveLen(2))
+		})
+
+		it("only provides when the extensions cannot be read", func() {
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, ".mvn"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, ".mvn", "extensions.xml"), []byte("<extensions>"), 0644)).To(Succeed())
+
+			result, err := detect.Detect(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Pass).To(BeTrue())
+			Expect(result.Plans).To(HaveLen(2))
+		})
+
+		it("explains the Polyglot Maven POM that was found", func() {
+			t.Setenv("BP_LOG_LEVEL", "DEBUG")
+			out := &bytes.Buffer{}
+			detect.Logger = bard.NewLogger(out)
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, ".mvn"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, ".mvn", "extensions.xml"), []byte(`<extensions>
+  <extension>
+    <groupId>io.takari.polyglot</groupId>
+    <artifactId>polyglot-yaml</artifactId>
+  </extension>
+</extensions>`), 0644)).To(Succeed())
+
+			_, err := detect.Detect(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(out.String()).To(ContainSubstring("Found POM %s, offering to build the application", filepath.Join(ctx.Application.Path, "pom.yaml")))
+		})
+	})
+
+	it("requires what every project in BP_NODE_PROJECT_PATHS needs", func() {
+		t.Setenv("BP_JAVA_INSTALL_NODE", "true")
+		t.Setenv("BP_NODE_PROJECT_PATHS", "admin,shop")
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte{}, 0644)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "admin"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "admin", "package-lock.json"), []byte{}, 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "admin", ".nvmrc"), []byte("20.14.0"), 0644)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "shop"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "shop", "pnpm-lock.yaml"), []byte{}, 0644)).To(Succeed())
+
+		result, err := detect.Detect(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, plan := range result.Plans[1:] {
+			Expect(plan.Requires).To(Equal([]libcnb.BuildPlanRequire{
+				{Name: "syft"},
+				{Name: "jdk"},
+				{Name: "maven"},
+				{Name: "node", Metadata: map[string]interface{}{"build": true, "version": "20.14.0", "version-source": ".nvmrc"}},
+				{Name: "pnpm", Metadata: map[string]interface{}{"build": true}},
+			}))
+		}
+	})
+
+	it("requires the Node version of a later project when an earlier one configures none", func() {
+		t.Setenv("BP_JAVA_INSTALL_NODE", "true")
+		t.Setenv("BP_NODE_PROJECT_PATHS", "a,b")
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte{}, 0644)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "a"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "a", "package.json"), []byte(`{}`), 0644)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "b"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "b", "package.json"), []byte(`{"engines": {"node": "20"}}`), 0644)).To(Succeed())
+
+		result, err := detect.Detect(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, plan := range result.Plans[1:] {
+			Expect(plan.Requires).To(ContainElement(libcnb.BuildPlanRequire{
+				Name:     "node",
+				Metadata: map[string]interface{}{"build": true, "version": "20", "version-source": "package.json"},
+			}))
+		}
+	})
+
+	context("the POM runs a frontend build", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte(`<project>
+  <build>
+    <plugins>
+      <plugin>
+        <groupId>com.github.eirslett</groupId>
+        <artifactId>frontend-maven-plugin</artifactId>
+        <executions>
+          <execution>
+            <goals>
+              <goal>install-node-and-pnpm</goal>
+              <goal>pnpm</goal>
+            </goals>
+          </execution>
+        </executions>
+      </plugin>
+    </plugins>
+  </build>
+</project>`), 0644)).To(Succeed())
+		})
+
+		it("requires node and the package manager", func() {
+			t.Setenv("BP_JAVA_INSTALL_NODE", "true")
+
+			result, err := detect.Detect(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, plan := range result.Plans[1:] {
+				Expect(plan.Requires).To(Equal([]libcnb.BuildPlanRequire{
+					{Name: "syft"},
+					{Name: "jdk"},
+					{Name: "maven"},
+					{Name: "node", Metadata: map[string]interface{}{"build": true}},
+					{Name: "pnpm", Metadata: map[string]interface{}{"build": true}},
+				}))
+			}
+		})
+
+		it("does not require node unless BP_JAVA_INSTALL_NODE is set", func() {
+			t.Setenv("BP_JAVA_INSTALL_NODE", "false")
+
+			result, err := detect.Detect(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Plans[2].Requires).To(Equal([]libcnb.BuildPlanRequire{
+				{Name: "syft"},
+				{Name: "jdk"},
+				{Name: "maven"},
+			}))
+		})
+	})
+
+	context("debug logging is enabled", func() {
+		var out *bytes.Buffer
+
+		it.Before(func() {
+			t.Setenv("BP_LOG_LEVEL", "DEBUG")
+			t.Setenv("BP_JAVA_INSTALL_NODE", "true")
+			t.Setenv("BP_NODE_PROJECT_PATH", "")
+			out = &bytes.Buffer{}
+			detect.Logger = bard.NewLogger(out)
+		})
+
+		it("explains a failure because of a manifest", func() {
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "META-INF"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "META-INF", "MANIFEST.MF"), []byte{}, 0644)).To(Succeed())
+
+			Expect(detect.Detect(ctx)).To(Equal(libcnb.DetectResult{}))
+			Expect(out.String()).To(ContainSubstring("Found %s, the application is already built, failing detection",
+				filepath.Join(ctx.Application.Path, "META-INF", "MANIFEST.MF")))
+		})
+
+		it("explains the POM that was checked", func() {
+			_, err := detect.Detect(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(out.String()).To(ContainSubstring("Unable to find POM %s, only offering to provide Maven",
+				filepath.Join(ctx.Application.Path, "pom.xml")))
+		})
+
+		it("explains the node files that were found", func() {
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte{}, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "yarn.lock"), []byte{}, 0644)).To(Succeed())
+
+			_, err := detect.Detect(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(out.String()).To(ContainSubstring("Found POM %s, offering to build the application", filepath.Join(ctx.Application.Path, "pom.xml")))
+			Expect(out.String()).To(ContainSubstring("Found a yarn project in %s", ctx.Application.Path))
+		})
+
+		it("does not explain without debug logging", func() {
+			t.Setenv("BP_LOG_LEVEL", "INFO")
+			detect.Logger = bard.NewLogger(out)
+
+			_, err := detect.Detect(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(out.String()).To(BeEmpty())
+		})
+	})
+
+	it("marks a Spring Boot application in the plan", func() {
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte(`<project>
+  <build>
+    <plugins>
+      <plugin>
+        <groupId>org.springframework.boot</groupId>
+        <artifactId>spring-boot-maven-plugin</artifactId>
+      </plugin>
+    </plugins>
+  </build>
+</project>`), 0644)).To(Succeed())
+
+		result, err := detect.Detect(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(result.Plans[0].Requires).To(Equal([]libcnb.BuildPlanRequire{{Name: "jdk"}}))
+		for _, plan := range result.Plans[1:] {
+			Expect(plan.Requires).To(ContainElement(libcnb.BuildPlanRequire{
+				Name:     "maven",
+				Metadata: map[string]interface{}{"spring-boot": true},
+			}))
+		}
+	})
+
+	context("BP_MAVEN_PROJECT_PATH is set", func() {
+		it.Before(func() {
+			t.Setenv("BP_JAVA_INSTALL_NODE", "false")
+			t.Setenv("BP_MAVEN_PROJECT_PATH", "api")
+		})
+
+		it("passes with pom.xml in the project", func() {
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "api"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "api", "pom.xml"), []byte("<project/>"), 0644)).To(Succeed())
+
+			result, err := detect.Detect(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Plans).To(HaveLen(3))
+			Expect(result.Plans[1].Requires).To(ContainElement(libcnb.BuildPlanRequire{Name: "maven"}))
+		})
+
+		it("only provides when the project does not exist", func() {
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte("<project/>"), 0644)).To(Succeed())
+
+			result, err := detect.Detect(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Plans).To(HaveLen(2))
+			Expect(result.Plans[1].Requires).To(BeEmpty())
+		})
+	})
+
+	context("the POM is in a subdirectory", func() {
+		it.Before(func() {
+			t.Setenv("BP_JAVA_INSTALL_NODE", "false")
+		})
+
+		it("builds the only root POM and passes it to the build", func() {
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "backend", "app"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "backend", "pom.xml"), []byte(`<project>
+  <packaging>pom</packaging>
+  <modules>
+    <module>app</module>
+  </modules>
+</project>`), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "backend", "app", "pom.xml"), []byte(`<project>
+  <parent>
+    <artifactId>backend</artifactId>
+  </parent>
+</project>`), 0644)).To(Succeed())
+
+			result, err := detect.Detect(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Pass).To(BeTrue())
+			Expect(result.Plans).To(HaveLen(3))
+			for _, plan := range result.Plans[1:] {
+				Expect(plan.Requires).To(ContainElement(libcnb.BuildPlanRequire{
+					Name:     "maven",
+					Metadata: map[string]interface{}{"pom-file": filepath.Join("backend", "pom.xml")},
+				}))
+			}
+		})
+
+		it("only provides when there are several root POMs", func() {
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "one"), 0755)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "two"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "one", "pom.xml"), []byte("<project/>"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "two", "pom.xml"), []byte("<project/>"), 0644)).To(Succeed())
+
+			result, err := detect.Detect(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Plans).To(HaveLen(2))
+			Expect(result.Plans[1].Requires).To(BeEmpty())
+		})
+
+		it("builds the POM that is the root of its build", func() {
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "platform"), 0755)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "service"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "platform", "pom.xml"), []byte(`<project>
+  <packaging>pom</packaging>
+</project>`), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "service", "pom.xml"), []byte(`<project xmlns="http://maven.apache.org/POM/4.1.0" root="true">
+  <modelVersion>4.1.0</modelVersion>
+  <parent>
+    <relativePath>../platform/pom.xml</relativePath>
+  </parent>
+</project>`), 0644)).To(Succeed())
+
+			result, err := detect.Detect(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Plans).To(HaveLen(3))
+			for _, plan := range result.Plans[1:] {
+				Expect(plan.Requires).To(ContainElement(libcnb.BuildPlanRequire{
+					Name:     "maven",
+					Metadata: map[string]interface{}{"pom-file": filepath.Join("service", "pom.xml")},
+				}))
+			}
+		})
+
+		it("does not look deeper than the search depth", func() {
+			dir := filepath.Join(ctx.Application.Path, "a", "b", "c", "d")
+			Expect(os.MkdirAll(dir, 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "pom.xml"), []byte("<project/>"), 0644)).To(Succeed())
+
+			result, err := detect.Detect(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Plans).To(HaveLen(2))
+			Expect(result.Plans[1].Requires).To(BeEmpty())
+		})
+
+		it("only offers to provide Maven when the search fails", func() {
+			ctx.Application.Path = filepath.Join(ctx.Application.Path, "missing")
+
+			result, err := detect.Detect(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Pass).To(BeTrue())
+			Expect(result.Plans).To(HaveLen(2))
+			Expect(result.Plans[1].Requires).To(BeEmpty())
+		})
+	})
+
+	it("marks a Quarkus application in the plan", func() {
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte(`<project>
+  <build>
+    <plugins>
+      <plugin>
+        <groupId>io.quarkus.platform</groupId>
+        <artifactId>quarkus-maven-plugin</artifactId>
+      </plugin>
+    </plugins>
+  </build>
+</project>`), 0644)).To(Succeed())
+
+		result, err := detect.Detect(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, plan := range result.Plans[1:] {
+			Expect(plan.Requires).To(ContainElement(libcnb.BuildPlanRequire{
+				Name:     "maven",
+				Metadata: map[string]interface{}{"quarkus": true},
+			}))
+		}
+	})
+
+	it("requires a native image builder when BP_NATIVE_IMAGE is true", func() {
+		t.Setenv("BP_NATIVE_IMAGE", "true")
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte(`<project>
+  <profiles>
+    <profile>
+      <id>native</id>
+    </profile>
+  </profiles>
+</project>`), 0644)).To(Succeed())
+
+		result, err := detect.Detect(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(result.Plans[2].Requires).To(ContainElement(libcnb.BuildPlanRequire{Name: "native-image-builder"}))
+	})
+
+	context("BP_MAVEN_NATIVE_IMAGE is true", func() {
+		it.Before(func() {
+			t.Setenv("BP_MAVEN_NATIVE_IMAGE", "true")
+		})
+
+		it("requires a native image builder when the project configures the native-maven-plugin", func() {
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte(`<project>
+  <build>
+    <plugins>
+      <plugin>
+        <groupId>org.graalvm.buildtools</groupId>
+        <artifactId>native-maven-plugin</artifactId>
+      </plugin>
+    </plugins>
+  </build>
+</project>`), 0644)).To(Succeed())
+
+			result, err := detect.Detect(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Plans).To(HaveLen(3))
+			for _, plan := range result.Plans[1:] {
+				Expect(plan.Requires).To(Equal([]libcnb.BuildPlanRequire{
+					{Name: "syft"},
+					{Name: "jdk"},
+					{Name: "maven"},
+					{Name: "native-image-builder"},
+				}))
+			}
+		})
+
+		it("does not require a native image builder when the project does not build a native image", func() {
+			Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte(`<project/>`), 0644)).To(Succeed())
+
+			result, err := detect.Detect(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Plans[2].Requires).To(Equal([]libcnb.BuildPlanRequire{
+				{Name: "syft"},
+				{Name: "jdk"},
+				{Name: "maven"},
+			}))
+		})
+	})
 }
@@ -19,6 +19,8 @@ package maven
 import (
 	"fmt"
 	"os"
+	"os/user"
+	"path/filepath"
 
 	"github.com/buildpacks/libcnb"
 	"github.com/paketo-buildpacks/libpak"
@@ -29,10 +31,19 @@ import (
 type Distribution struct {
 	LayerContributor libpak.DependencyLayerContributor
 	Logger           bard.Logger
+	Verifier         *SignatureVerifier
+}
+
+// verifiedDependency is the metadata of a distribution layer that is verified, so that the layer is contributed again,
+// and verified, when the keys to trust change
+type verifiedDependency struct {
+	libpak.BuildpackDependency
+	KeysSHA256 string `toml:"keys-sha256"`
 }
 
 func NewDistribution(dependency libpak.BuildpackDependency, cache libpak.DependencyCache) (Distribution, libcnb.BOMEntry) {
 	contributor, entry := libpak.NewDependencyLayer(dependency, cache, libcnb.LayerTypes{
+		Build: true,
 		Cache: true,
 	})
 	return Distribution{LayerContributor: contributor}, entry
@@ -40,8 +51,22 @@ func NewDistribution(dependency libpak.BuildpackDependency, cache libpak.Depende
 
 func (d Distribution) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
 	d.LayerContributor.Logger = d.Logger
+	if d.Verifier != nil {
+		d.LayerContributor.ExpectedMetadata = verifiedDependency{
+			BuildpackDependency: d.LayerContributor.Dependency,
+			KeysSHA256:          d.Verifier.KeysSHA256,
+		}
+	}
+
+	layer, err := d.LayerContributor.Contribute(layer, func(artifact *os.File) (libcnb.Layer, error) {
+		if d.Verifier != nil {
+			signer, err := d.Verifier.Verify(artifact)
+			if err != nil {
+				return libcnb.Layer{}, fmt.Errorf("unable to verify Maven\n%w", err)
+			}
+			d.Logger.Bodyf("Verified signature of %s", signer)
+		}
 
-	return d.LayerContributor.Contribute(layer, func(artifact *os.File) (libcnb.Layer, error) {
 		d.Logger.Bodyf("Expanding to %s", layer.Path)
 		if err := crush.ExtractTarGz(artifact, layer.Path, 1); err != nil {
 			return libcnb.Layer{}, fmt.Errorf("unable to expand Maven\n%w", err)
@@ -49,6 +74,39 @@ func (d Distribution) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
 
 		return layer, nil
 	})
+	if err != nil {
+		return libcnb.Layer{}, err
+	}
+
+	for k, v := range MavenEnvironment(layer.Path) {
+		layer.BuildEnvironment.Override(k, v)
+	}
+
+	return layer, nil
+}
+
+// MavenHome returns where the distribution is installed
+func (d Distribution) MavenHome(layersPath string) string {
+	return filepath.Join(layersPath, d.Name())
+}
+
+// MavenEnvironment returns the environment variables that locate a Maven installation and the local repository,
+// for scripts and plugins that fork Maven
+func MavenEnvironment(home string) map[string]string {
+	return map[string]string{
+		"MAVEN_HOME":       home,
+		"M2_HOME":          home,
+		"MAVEN_REPO_LOCAL": localRepository(),
+	}
+}
+
+// localRepository returns the location of the local repository, within the cached ~/.m2
+func localRepository() string {
+	home := os.Getenv("HOME")
+	if u, err := user.Current(); err == nil {
+		home = u.HomeDir
+	}
+	return filepath.Join(home, ".m2", "repository")
 }
 
 func (d Distribution) Name() string {
@@ -65,4 +65,24 @@ func testDistribution(t *testing.T, context spec.G, it spec.S) {
 		Expect(filepath.Join(layer.Path, "fixture-marker")).To(BeARegularFile())
 	})
 
+	it("exposes Maven to the build", func() {
+		dep := libpak.BuildpackDependency{
+			URI:    "https://localhost/stub-maven-distribution.tar.gz",
+			SHA256: "31ba45356e22aff670af88170f43ff82328e6f323c3ce891ba422bd1031e3308",
+		}
+		dc := libpak.DependencyCache{CachePath: "testdata"}
+
+		d, _ := maven.NewDistribution(dep, dc)
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err = d.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(layer.Build).To(BeTrue())
+		Expect(layer.BuildEnvironment["MAVEN_HOME.override"]).To(Equal(layer.Path))
+		Expect(layer.BuildEnvironment["M2_HOME.override"]).To(Equal(layer.Path))
+		Expect(layer.BuildEnvironment["MAVEN_REPO_LOCAL.override"]).To(HaveSuffix(filepath.Join(".m2", "repository")))
+	})
+
 }
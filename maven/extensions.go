@@ -0,0 +1,168 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultExtensionsNamespace is the namespace of an extensions.xml written when the project does not have one
+const DefaultExtensionsNamespace = "http://maven.apache.org/EXTENSIONS/1.0.0"
+
+// Extensions is the content of a Maven core extensions file, .mvn/extensions.xml
+type Extensions struct {
+	XMLName    xml.Name    `xml:"extensions"`
+	Extensions []Extension `xml:"extension"`
+
+	// document is the file the extensions were read from, which is kept as is when extensions are added to it, and
+	// documented the number of extensions it declares
+	document   []byte
+	documented int
+}
+
+// Extension is a single core extension.  Its content is kept as is so that any elements besides the coordinates
+// survive a merge.
+type Extension struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Content    string `xml:",innerxml"`
+}
+
+func (e Extension) key() string {
+	return fmt.Sprintf("%s:%s", strings.TrimSpace(e.GroupID), strings.TrimSpace(e.ArtifactID))
+}
+
+// ReadExtensions reads a core extensions file.  A file that does not exist has no extensions.
+func ReadExtensions(file string) (Extensions, bool, error) {
+	b, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return Extensions{}, false, nil
+	} else if err != nil {
+		return Extensions{}, false, fmt.Errorf("unable to read %s\n%w", file, err)
+	}
+
+	var e Extensions
+	if err := xml.Unmarshal(b, &e); err != nil {
+		return Extensions{}, false, fmt.Errorf("unable to parse %s\n%w", file, err)
+	}
+	e.document, e.documented = b, len(e.Extensions)
+
+	return e, true, nil
+}
+
+// Merge adds the extensions that are not already declared.  Existing declarations win, so a project can pin a
+// different version of an extension than the one provided.
+func (e Extensions) Merge(other Extensions) (Extensions, []string) {
+	declared := map[string]bool{}
+	for _, ext := range e.Extensions {
+		declared[ext.key()] = true
+	}
+
+	merged := Extensions{
+		XMLName:    e.XMLName,
+		Extensions: append([]Extension{}, e.Extensions...),
+		document:   e.document,
+		documented: e.documented,
+	}
+	if merged.XMLName.Space == "" {
+		merged.XMLName = other.XMLName
+	}
+	var added []string
+	for _, ext := range other.Extensions {
+		if declared[ext.key()] {
+			continue
+		}
+		declared[ext.key()] = true
+		merged.Extensions = append(merged.Extensions, ext)
+		added = append(added, ext.key())
+	}
+
+	return merged, added
+}
+
+// Bytes encodes the extensions as an extensions.xml document.  The extensions added to a file that was read are
+// inserted at the end of it, so that its comments, attributes and formatting are kept.
+func (e Extensions) Bytes() []byte {
+	if end := bytes.LastIndex(e.document, []byte("</extensions>")); end >= 0 {
+		var b bytes.Buffer
+		b.Write(e.document[:end])
+		for _, ext := range e.Extensions[e.documented:] {
+			fmt.Fprintf(&b, "  <extension>%s</extension>\n", ext.Content)
+		}
+		b.Write(e.document[end:])
+		return b.Bytes()
+	}
+
+	namespace := e.XMLName.Space
+	if namespace == "" {
+		namespace = DefaultExtensionsNamespace
+	}
+
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	fmt.Fprintf(&b, "<extensions xmlns=\"%s\">\n", namespace)
+	for _, ext := range e.Extensions {
+		fmt.Fprintf(&b, "  <extension>%s</extension>\n", ext.Content)
+	}
+	b.WriteString("</extensions>\n")
+
+	return b.Bytes()
+}
+
+// addExtensions adds extensions to the project's .mvn/extensions.xml, recording the digest of the result in the layer
+// metadata.  The file is only written if an extension is added, and not in a dry run.
+func (b Build) addExtensions(provided Extensions, projectPath string, md map[string]interface{}) error {
+	file := filepath.Join(projectPath, ".mvn", "extensions.xml")
+	project, _, err := ReadExtensions(file)
+	if err != nil {
+		return err
+	}
+
+	merged, added := project.Merge(provided)
+	content := merged.Bytes()
+	sum := sha256.Sum256(content)
+	md["extensions-sha256"] = hex.EncodeToString(sum[:])
+
+	if len(added) == 0 {
+		return nil
+	}
+	if b.configResolver.ResolveBool("BP_MAVEN_DRY_RUN") {
+		for _, a := range added {
+			b.Logger.Bodyf("Would add Maven extension %s", a)
+		}
+		return nil
+	}
+
+	for _, a := range added {
+		b.Logger.Bodyf("Adding Maven extension %s", a)
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("unable to create directory %s\n%w", filepath.Dir(file), err)
+	}
+	if err := os.WriteFile(file, content, 0644); err != nil {
+		return fmt.Errorf("unable to write %s\n%w", file, err)
+	}
+
+	return nil
+}
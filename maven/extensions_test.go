@@ -0,0 +1,114 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/maven/v6/maven"
+)
+
+func testExtensions(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		path string
+	)
+
+	it.Before(func() {
+		var err error
+		path, err = os.MkdirTemp("", "extensions")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(path)).To(Succeed())
+	})
+
+	it("reads nothing from a missing file", func() {
+		e, ok, err := maven.ReadExtensions(filepath.Join(path, "extensions.xml"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeFalse())
+		Expect(e.Extensions).To(BeEmpty())
+	})
+
+	it("merges extensions, keeping existing declarations and the rest of the document", func() {
+		project := filepath.Join(path, "project.xml")
+		Expect(os.WriteFile(project, []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<extensions xmlns="http://maven.apache.org/EXTENSIONS/1.1.0" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
+    xsi:schemaLocation="http://maven.apache.org/EXTENSIONS/1.1.0 https://maven.apache.org/xsd/core-extensions-1.1.0.xsd">
+  <!-- pinned until the cache is migrated -->
+  <extension>
+    <groupId>org.apache.maven.extensions</groupId>
+    <artifactId>maven-build-cache-extension</artifactId>
+    <version>1.2.0</version>
+  </extension>
+</extensions>`), 0644)).To(Succeed())
+
+		provided := filepath.Join(path, "provided.xml")
+		Expect(os.WriteFile(provided, []byte(`<extensions>
+  <extension>
+    <groupId>org.apache.maven.extensions</groupId>
+    <artifactId>maven-build-cache-extension</artifactId>
+    <version>1.0.0</version>
+  </extension>
+  <extension>
+    <groupId>com.example</groupId>
+    <artifactId>custom-wagon</artifactId>
+    <version>2.0.0</version>
+  </extension>
+</extensions>`), 0644)).To(Succeed())
+
+		p, ok, err := maven.ReadExtensions(project)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeTrue())
+		o, _, err := maven.ReadExtensions(provided)
+		Expect(err).NotTo(HaveOccurred())
+
+		merged, added := p.Merge(o)
+		Expect(added).To(Equal([]string{"com.example:custom-wagon"}))
+		Expect(string(merged.Bytes())).To(Equal(`<?xml version="1.0" encoding="UTF-8"?>
+<extensions xmlns="http://maven.apache.org/EXTENSIONS/1.1.0" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
+    xsi:schemaLocation="http://maven.apache.org/EXTENSIONS/1.1.0 https://maven.apache.org/xsd/core-extensions-1.1.0.xsd">
+  <!-- pinned until the cache is migrated -->
+  <extension>
+    <groupId>org.apache.maven.extensions</groupId>
+    <artifactId>maven-build-cache-extension</artifactId>
+    <version>1.2.0</version>
+  </extension>
+  <extension>
+    <groupId>com.example</groupId>
+    <artifactId>custom-wagon</artifactId>
+    <version>2.0.0</version>
+  </extension>
+</extensions>`))
+	})
+
+	it("uses the default namespace without one", func() {
+		merged, _ := maven.Extensions{}.Merge(maven.Extensions{Extensions: []maven.Extension{
+			{GroupID: "g", ArtifactID: "a", Content: "<groupId>g</groupId><artifactId>a</artifactId>"},
+		}})
+
+		Expect(string(merged.Bytes())).To(ContainSubstring(`<extensions xmlns="http://maven.apache.org/EXTENSIONS/1.0.0">`))
+		Expect(string(merged.Bytes())).To(ContainSubstring("<extension><groupId>g</groupId><artifactId>a</artifactId></extension>"))
+	})
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// FrontendGroupID and FrontendArtifactID are the coordinates of the frontend-maven-plugin
+	FrontendGroupID    = "com.github.eirslett"
+	FrontendArtifactID = "frontend-maven-plugin"
+
+	// ExecGroupID and ExecArtifactID are the coordinates of the exec-maven-plugin
+	ExecGroupID    = "org.codehaus.mojo"
+	ExecArtifactID = "exec-maven-plugin"
+
+	PackageManagerNPM  = "npm"
+	PackageManagerYarn = "yarn"
+	PackageManagerPNPM = "pnpm"
+)
+
+// frontendGoals maps the goals of the frontend-maven-plugin to the package manager they run
+var frontendGoals = map[string]string{
+	"install-node-and-npm":  PackageManagerNPM,
+	"npm":                   PackageManagerNPM,
+	"npx":                   PackageManagerNPM,
+	"install-node-and-yarn": PackageManagerYarn,
+	"yarn":                  PackageManagerYarn,
+	"install-node-and-pnpm": PackageManagerPNPM,
+	"pnpm":                  PackageManagerPNPM,
+}
+
+// frontendSkipInstall maps the goals of the frontend-maven-plugin that download Node to the property that skips them
+var frontendSkipInstall = map[string]string{
+	"install-node-and-npm":  "skip.installnodenpm",
+	"install-node-and-yarn": "skip.installyarn",
+	"install-node-and-pnpm": "skip.installnodepnpm",
+}
+
+// FrontendExecution is a frontend build run by a Maven plugin
+type FrontendExecution struct {
+	// Directory is the working directory of the build
+	Directory string
+
+	// PackageManager is the package manager the build runs, npm, yarn or pnpm
+	PackageManager string
+
+	// SkipInstall is the property that skips the download of Node by the plugin, if it downloads it
+	SkipInstall string
+}
+
+// FrontendExecutions returns the frontend builds run by the frontend-maven-plugin or the exec-maven-plugin in the
+// projects of a reactor
+func FrontendExecutions(projects []ReactorProject) []FrontendExecution {
+	var executions []FrontendExecution
+
+	for _, project := range projects {
+		dir := filepath.Dir(project.File)
+
+		if plugin, ok := project.POM.Plugin(FrontendGroupID, FrontendArtifactID); ok {
+			for _, e := range plugin.Executions {
+				for _, goal := range e.Goals {
+					goal = strings.TrimSpace(goal)
+					if manager, ok := frontendGoals[goal]; ok {
+						executions = append(executions, FrontendExecution{
+							Directory:      workingDirectory(project.POM, dir, e.WorkingDirectory, plugin.WorkingDirectory),
+							PackageManager: manager,
+							SkipInstall:    frontendSkipInstall[goal],
+						})
+					}
+				}
+			}
+		}
+
+		if plugin, ok := project.POM.Plugin(ExecGroupID, ExecArtifactID); ok {
+			for _, e := range plugin.Executions {
+				executable := e.Executable
+				if strings.TrimSpace(executable) == "" {
+					executable = plugin.Executable
+				}
+				executable = strings.TrimSpace(filepath.Base(executable))
+				executable = strings.TrimSuffix(strings.TrimSuffix(executable, ".cmd"), ".exe")
+
+				directory := workingDirectory(project.POM, dir, e.WorkingDirectory, plugin.WorkingDirectory)
+				switch executable {
+				case PackageManagerNPM, PackageManagerYarn, PackageManagerPNPM:
+					executions = append(executions, FrontendExecution{Directory: directory, PackageManager: executable})
+				case "node", "npx":
+					executions = append(executions, FrontendExecution{Directory: directory, PackageManager: LockedPackageManager(directory)})
+				}
+			}
+		}
+	}
+
+	return executions
+}
+
+// LockedPackageManager returns the package manager whose lockfile is in dir, defaulting to npm
+func LockedPackageManager(dir string) string {
+	if _, err := os.Stat(filepath.Join(dir, "pnpm-lock.yaml")); err == nil {
+		return PackageManagerPNPM
+	}
+	if _, err := os.Stat(filepath.Join(dir, "yarn.lock")); err == nil {
+		return PackageManagerYarn
+	}
+	return PackageManagerNPM
+}
+
+// workingDirectory resolves the working directory configured for an execution, or else for its plugin, against the
+// directory of the project.  A directory that cannot be resolved from the POM alone is the project directory.
+func workingDirectory(pom POM, dir string, configured ...string) string {
+	for _, c := range configured {
+		if c = strings.TrimSpace(c); c == "" {
+			continue
+		}
+
+		for _, basedir := range []string{"${project.basedir}", "${basedir}"} {
+			c = strings.ReplaceAll(c, basedir, dir)
+		}
+		if c = pom.Interpolate(c); strings.Contains(c, "${") {
+			return dir
+		}
+
+		if !filepath.IsAbs(c) {
+			c = filepath.Join(dir, c)
+		}
+		return filepath.Clean(c)
+	}
+
+	return dir
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/maven/v6/maven"
+)
+
+func testFrontend(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		path string
+	)
+
+	it.Before(func() {
+		var err error
+
+		path, err = os.MkdirTemp("", "frontend")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(path)).To(Succeed())
+	})
+
+	it("finds the frontend-maven-plugin executions of a reactor", func() {
+		Expect(os.WriteFile(filepath.Join(path, "pom.xml"), []byte(`<project>
+  <modules>
+    <module>ui</module>
+  </modules>
+</project>`), 0644)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(path, "ui"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, "ui", "pom.xml"), []byte(`<project>
+  <build>
+    <plugins>
+      <plugin>
+        <groupId>com.github.eirslett</groupId>
+        <artifactId>frontend-maven-plugin</artifactId>
+        <configuration>
+          <workingDirectory>${project.basedir}/src/main/frontend</workingDirectory>
+        </configuration>
+        <executions>
+          <execution>
+            <id>install</id>
+            <goals>
+              <goal>install-node-and-yarn</goal>
+            </goals>
+          </execution>
+          <execution>
+            <id>build</id>
+            <goals>
+              <goal>yarn</goal>
+            </goals>
+            <configuration>
+              <workingDirectory>app</workingDirectory>
+            </configuration>
+          </execution>
+        </executions>
+      </plugin>
+    </plugins>
+  </build>
+</project>`), 0644)).To(Succeed())
+
+		Expect(maven.FrontendExecutions(maven.ReadReactor(filepath.Join(path, "pom.xml")))).To(Equal([]maven.FrontendExecution{
+			{Directory: filepath.Join(path, "ui", "src", "main", "frontend"), PackageManager: "yarn", SkipInstall: "skip.installyarn"},
+			{Directory: filepath.Join(path, "ui", "app"), PackageManager: "yarn"},
+		}))
+	})
+
+	it("finds the exec-maven-plugin executions that run a package manager", func() {
+		Expect(os.MkdirAll(filepath.Join(path, "web"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, "web", "pnpm-lock.yaml"), []byte{}, 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, "pom.xml"), []byte(`<project>
+  <build>
+    <plugins>
+      <plugin>
+        <groupId>org.codehaus.mojo</groupId>
+        <artifactId>exec-maven-plugin</artifactId>
+        <executions>
+          <execution>
+            <id>install</id>
+            <configuration>
+              <executable>npm</executable>
+            </configuration>
+          </execution>
+          <execution>
+            <id>build</id>
+            <configuration>
+              <executable>node</executable>
+              <workingDirectory>web</workingDirectory>
+            </configuration>
+          </execution>
+          <execution>
+            <id>other</id>
+            <configuration>
+              <executable>java</executable>
+            </configuration>
+          </execution>
+        </executions>
+      </plugin>
+    </plugins>
+  </build>
+</project>`), 0644)).To(Succeed())
+
+		Expect(maven.FrontendExecutions(maven.ReadReactor(filepath.Join(path, "pom.xml")))).To(Equal([]maven.FrontendExecution{
+			{Directory: path, PackageManager: "npm"},
+			{Directory: filepath.Join(path, "web"), PackageManager: "pnpm"},
+		}))
+	})
+
+	it("finds the package manager of a lockfile", func() {
+		Expect(maven.LockedPackageManager(path)).To(Equal("npm"))
+
+		Expect(os.WriteFile(filepath.Join(path, "yarn.lock"), []byte{}, 0644)).To(Succeed())
+		Expect(maven.LockedPackageManager(path)).To(Equal("yarn"))
+	})
+}
@@ -25,10 +25,27 @@ import (
 
 func TestUnit(t *testing.T) {
 	suite := spec.New("maven", spec.Report(report.Terminal{}))
+	suite("Arguments", testArguments)
 	suite("Build", testBuild)
+	suite("BuildCache", testBuildCache)
 	suite("Detect", testDetect)
+	suite("Extensions", testExtensions)
+	suite("Frontend", testFrontend)
 	suite("MavenManagers", testMavenManager)
 	suite("Distribution", testDistribution)
 	suite("MvndDistribution", testMvndDistribution)
+	suite("Native", testNative)
+	suite("Node", testNode)
+	suite("POM", testPOM)
+	suite("Polyglot", testPolyglot)
+	suite("Profiles", testProfiles)
+	suite("Project", testProject)
+	suite("Quarkus", testQuarkus)
+	suite("Report", testReport)
+	suite("Resources", testResources)
+	suite("Signature", testSignature)
+	suite("SpringBoot", testSpringBoot)
+	suite("TargetCache", testTargetCache)
+	suite("Timing", testTiming)
 	suite.Run(t)
 }
@@ -6,7 +6,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"runtime"
+	"strings"
 
 	"github.com/buildpacks/libcnb"
 	"github.com/paketo-buildpacks/libpak"
@@ -19,8 +22,24 @@ type MavenManager interface {
 	Install() (string, libcnb.LayerContributor, *libcnb.BOMEntry, error)
 }
 
+const (
+	// DaemonMinimumProjects is the number of projects in the reactor from which BP_MAVEN_DAEMON_ENABLED=auto uses the
+	// Maven Daemon
+	DaemonMinimumProjects = 10
+
+	// DaemonMinimumCPUs is the number of CPUs from which BP_MAVEN_DAEMON_ENABLED=auto uses the Maven Daemon
+	DaemonMinimumCPUs = 4
+)
+
+// UseDaemon determines if a reactor of projects is worth building with the Maven Daemon in a build container.  Like
+// a parallel build, it requires a CPU quota, as the CPUs of the host are not necessarily available to the build.
+func UseDaemon(projects int, resources ContainerResources) bool {
+	return projects >= DaemonMinimumProjects && resources.CPULimited && resources.CPUs >= DaemonMinimumCPUs
+}
+
 // DaemonMavenManager provides the Maven daemon based Maven distribution
 type DaemonMavenManager struct {
+	appPath        string
 	configResolver libpak.ConfigurationResolver
 	depCache       libpak.DependencyCache
 	depResolver    libpak.DependencyResolver
@@ -28,8 +47,9 @@ type DaemonMavenManager struct {
 	logger         bard.Logger
 }
 
-func NewDaemonMavenManager(configResolver libpak.ConfigurationResolver, depResolver libpak.DependencyResolver, depCache libpak.DependencyCache, layersPath string, logger bard.Logger) DaemonMavenManager {
+func NewDaemonMavenManager(appPath string, configResolver libpak.ConfigurationResolver, depResolver libpak.DependencyResolver, depCache libpak.DependencyCache, layersPath string, logger bard.Logger) DaemonMavenManager {
 	return DaemonMavenManager{
+		appPath:        appPath,
 		configResolver: configResolver,
 		depResolver:    depResolver,
 		depCache:       depCache,
@@ -38,17 +58,48 @@ func NewDaemonMavenManager(configResolver libpak.ConfigurationResolver, depResol
 	}
 }
 
-// ShouldInstall the Maven Daemon
+// ShouldInstall the Maven Daemon, if it is enabled and a distribution is available for the current architecture.
+// When it is not available, the next MavenManager is used instead.
 func (d DaemonMavenManager) ShouldInstall() bool {
-	return d.configResolver.ResolveBool("BP_MAVEN_DAEMON_ENABLED")
+	if enabled, _ := d.configResolver.Resolve("BP_MAVEN_DAEMON_ENABLED"); strings.EqualFold(enabled, "auto") {
+		if !d.worthwhile() {
+			return false
+		}
+	} else if !d.configResolver.ResolveBool("BP_MAVEN_DAEMON_ENABLED") {
+		return false
+	}
+
+	if _, err := d.depResolver.Resolve("mvnd", ""); err != nil {
+		d.logger.Bodyf("WARNING: maven daemon (mvnd) is not available on %s, falling back to Maven", arch())
+		return false
+	}
+
+	return true
 }
 
-// Install the Maven daemon tool
-func (d DaemonMavenManager) Install() (string, libcnb.LayerContributor, *libcnb.BOMEntry, error) {
-	if runtime.GOARCH == "s390x" || runtime.GOARCH == "ppc64le" {
-		return "", nil, nil, fmt.Errorf("maven daemon (mvnd) is not supported on %s", runtime.GOARCH)
+// worthwhile determines if the reactor is large enough, and the build container has enough CPUs, for the Maven Daemon
+// to build faster than Maven
+func (d DaemonMavenManager) worthwhile() bool {
+	file, _ := d.configResolver.Resolve("BP_MAVEN_POM_FILE")
+	projects := CountProjects(pomFile(filepath.Join(d.appPath, file)))
+	resources := DetectContainerResources(CgroupRoot)
+
+	if UseDaemon(projects, resources) {
+		d.logger.Bodyf("Using the Maven Daemon for %d projects and %d CPUs", projects, resources.CPUs)
+		return true
 	}
 
+	if !resources.CPULimited {
+		d.logger.Bodyf("Not using the Maven Daemon for %d projects, the build container has no CPU quota", projects)
+		return false
+	}
+	d.logger.Bodyf("Not using the Maven Daemon for %d projects and %d CPUs, it requires at least %d projects and %d CPUs",
+		projects, resources.CPUs, DaemonMinimumProjects, DaemonMinimumCPUs)
+	return false
+}
+
+// Install the Maven daemon tool
+func (d DaemonMavenManager) Install() (string, libcnb.LayerContributor, *libcnb.BOMEntry, error) {
 	dep, err := d.depResolver.Resolve("mvnd", "")
 	if err != nil {
 		return "", nil, nil, fmt.Errorf("unable to find dependency\n%w", err)
@@ -56,12 +107,34 @@ func (d DaemonMavenManager) Install() (string, libcnb.LayerContributor, *libcnb.
 
 	dist, be := NewMvndDistribution(dep, d.depCache)
 	dist.Logger = d.logger
+	dist.Properties = d.properties()
 
 	command := filepath.Join(d.layersPath, dist.Name(), "bin", "mvnd")
 
 	return command, dist, &be, nil
 }
 
+// properties returns the mvnd.properties configured by the user
+func (d DaemonMavenManager) properties() map[string]string {
+	properties := map[string]string{}
+	for key, property := range map[string]string{
+		"BP_MAVEN_DAEMON_THREADS":       "mvnd.threads",
+		"BP_MAVEN_DAEMON_MIN_HEAP_SIZE": "mvnd.minHeapSize",
+		"BP_MAVEN_DAEMON_MAX_HEAP_SIZE": "mvnd.maxHeapSize",
+		"BP_MAVEN_DAEMON_IDLE_TIMEOUT":  "mvnd.idleTimeout",
+	} {
+		if value, _ := d.configResolver.Resolve(key); value != "" {
+			properties[property] = value
+		}
+	}
+
+	if d.configResolver.ResolveBool("BP_MAVEN_DAEMON_NO_DAEMON") {
+		properties["mvnd.noDaemon"] = "true"
+	}
+
+	return properties
+}
+
 // StandardMavenManager provides the standard JVM-based Maven distribution
 type StandardMavenManager struct {
 	appPath        string
@@ -83,12 +156,23 @@ func NewStandardMavenManager(appPath string, configResolver libpak.Configuration
 	}
 }
 
-// ShouldInstall the standard JVM-based Maven distribution
+// ShouldInstall the standard JVM-based Maven distribution, unless there is a Maven Wrapper, or Maven is installed at
+// $MAVEN_HOME or $M2_HOME in the version $BP_MAVEN_VERSION requests
 func (s StandardMavenManager) ShouldInstall() bool {
 	command := filepath.Join(s.appPath, "mvnw")
 	_, err := os.Stat(command)
 	mvnwNotFound := os.IsNotExist(err)
 
+	if home, ok := mavenHome(); ok {
+		expected, userSet := s.configResolver.Resolve("BP_MAVEN_VERSION")
+		if version := installedMavenVersion(home); mvnwNotFound && userSet && !matchesMavenVersion(version, expected) {
+			s.logger.Bodyf("Installing Maven %s as $BP_MAVEN_VERSION requests, instead of using the Maven %s installed at %s",
+				expected, version, home)
+			return true
+		}
+		return false
+	}
+
 	_, err = exec.LookPath("mvn")
 	mvnNotOnPath := err != nil // or lookup failure
 
@@ -97,7 +181,14 @@ func (s StandardMavenManager) ShouldInstall() bool {
 
 // Install the standard JVM-based Maven distribution
 func (s StandardMavenManager) Install() (string, libcnb.LayerContributor, *libcnb.BOMEntry, error) {
-	version, _ := s.configResolver.Resolve("BP_MAVEN_VERSION")
+	version, userSet := s.configResolver.Resolve("BP_MAVEN_VERSION")
+	if !userSet {
+		file, _ := s.configResolver.Resolve("BP_MAVEN_POM_FILE")
+		if pom, err := ReadPOM(pomFile(filepath.Join(s.appPath, file))); err == nil && pom.IsMaven4() {
+			s.logger.Bodyf("Using Maven 4 as the POM uses model version %s", ModelVersion4)
+			version = "4"
+		}
+	}
 
 	dep, err := s.depResolver.Resolve("maven", version)
 	if err != nil {
@@ -136,7 +227,7 @@ func (w WrapperMavenManager) ShouldInstall() bool {
 // Slightly misleading as this doesn't install anything, it just makes sure the wrapper can be run
 // The wrapper itself handles any installation, if it's necessary
 func (w WrapperMavenManager) Install() (string, libcnb.LayerContributor, *libcnb.BOMEntry, error) {
-	command := filepath.Join(w.appPath, "mvnw")
+	command := w.Command()
 
 	if err := os.Chmod(command, 0755); err != nil {
 		w.logger.Bodyf("WARNING: unable to chmod %s:\n%s", command, err)
@@ -156,6 +247,26 @@ func (w WrapperMavenManager) Install() (string, libcnb.LayerContributor, *libcnb
 	return command, nil, nil, nil
 }
 
+// Command returns the path of the Maven Wrapper
+func (w WrapperMavenManager) Command() string {
+	return filepath.Join(w.appPath, "mvnw")
+}
+
+var wrapperDistributionURL = regexp.MustCompile(`(?m)^\s*distributionUrl\s*=.*/(?:apache-maven|maven-mvnd)-([^/]+?)-(?:bin|linux-[a-z0-9]+)\.(?:zip|tar\.gz)\s*$`)
+
+// Version returns the version of Maven configured in the wrapper properties, if any
+func (w WrapperMavenManager) Version() string {
+	b, err := os.ReadFile(filepath.Join(w.appPath, ".mvn/wrapper/maven-wrapper.properties"))
+	if err != nil {
+		return ""
+	}
+
+	if m := wrapperDistributionURL.FindSubmatch(b); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
 func (w WrapperMavenManager) cleanMvnWrapper(fileName string) error {
 	fileContents, err := os.ReadFile(fileName)
 	if err != nil {
@@ -175,26 +286,38 @@ func (w WrapperMavenManager) cleanMvnWrapper(fileName string) error {
 	return nil
 }
 
-// NoopMavenManager doesn't provide Maven, but expects it to exist on the path
+// NoopMavenManager doesn't provide Maven, but expects it to be installed at $MAVEN_HOME or $M2_HOME, or to exist on
+// the path
 type NoopMavenManager struct {
-	logger bard.Logger
+	configResolver libpak.ConfigurationResolver
+	logger         bard.Logger
 }
 
-func NewNoopMavenManager(logger bard.Logger) NoopMavenManager {
+func NewNoopMavenManager(configResolver libpak.ConfigurationResolver, logger bard.Logger) NoopMavenManager {
 	return NoopMavenManager{
-		logger: logger,
+		configResolver: configResolver,
+		logger:         logger,
 	}
 }
 
-// ShouldInstall determines if Maven is on the $PATH
+// ShouldInstall determines if Maven is installed at $MAVEN_HOME or $M2_HOME, or is on the $PATH
 func (n NoopMavenManager) ShouldInstall() bool {
+	if _, ok := mavenHome(); ok {
+		return true
+	}
+
 	path, err := exec.LookPath("mvn")
 	return path != "" && err == nil
 }
 
 // Install nothing.
-// Slightly misleading as this doesn't install anything, it just makes sure mvn is on the $PATH
+// Slightly misleading as this doesn't install anything, it just makes sure mvn is at $MAVEN_HOME or $M2_HOME, or on
+// the $PATH.  An installation at $MAVEN_HOME or $M2_HOME is checked against $BP_MAVEN_VERSION and recorded in the BOM.
 func (n NoopMavenManager) Install() (string, libcnb.LayerContributor, *libcnb.BOMEntry, error) {
+	if home, ok := mavenHome(); ok {
+		return n.installed(home)
+	}
+
 	command, err := exec.LookPath("mvn")
 	if err != nil {
 		return "", nil, nil, fmt.Errorf("unable to lookup 'mvn'\n%w", err)
@@ -206,3 +329,79 @@ func (n NoopMavenManager) Install() (string, libcnb.LayerContributor, *libcnb.BO
 
 	return command, nil, nil, nil
 }
+
+func (n NoopMavenManager) installed(home string) (string, libcnb.LayerContributor, *libcnb.BOMEntry, error) {
+	version := installedMavenVersion(home)
+	n.logger.Bodyf("Using Maven %s installed at %s", version, home)
+
+	if expected, ok := n.configResolver.Resolve("BP_MAVEN_VERSION"); ok {
+		if version == "" {
+			return "", nil, nil, fmt.Errorf("unable to determine the version of Maven installed at %s to compare with $BP_MAVEN_VERSION %s", home, expected)
+		}
+		if !matchesMavenVersion(version, expected) {
+			return "", nil, nil, fmt.Errorf("installed Maven %s at %s does not match $BP_MAVEN_VERSION %s, "+
+				"unset $MAVEN_HOME and $M2_HOME or $BP_MAVEN_VERSION", version, home, expected)
+		}
+	}
+
+	be := libcnb.BOMEntry{
+		Name: "maven",
+		Metadata: map[string]interface{}{
+			"name":    "Apache Maven",
+			"version": version,
+			"path":    home,
+		},
+		Build: true,
+	}
+
+	return filepath.Join(home, "bin", "mvn"), nil, &be, nil
+}
+
+// mavenHome returns the Maven installation $MAVEN_HOME, or else $M2_HOME, points at, if it has a mvn command
+func mavenHome() (string, bool) {
+	for _, name := range []string{"MAVEN_HOME", "M2_HOME"} {
+		home := os.Getenv(name)
+		if home == "" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(home, "bin", "mvn")); err == nil {
+			return home, true
+		}
+	}
+	return "", false
+}
+
+var mavenCoreJAR = regexp.MustCompile(`^maven-core-(.+)\.jar$`)
+
+// installedMavenVersion returns the version of a Maven installation, from the maven-core JAR in its lib directory
+func installedMavenVersion(home string) string {
+	entries, err := os.ReadDir(filepath.Join(home, "lib"))
+	if err != nil {
+		return ""
+	}
+
+	for _, e := range entries {
+		if m := mavenCoreJAR.FindStringSubmatch(e.Name()); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// matchesMavenVersion determines if an installed version of Maven is the expected version, or one of its patches
+func matchesMavenVersion(version string, expected string) bool {
+	return version == expected || strings.HasPrefix(version, expected+".")
+}
+
+// managerName returns the type name of a MavenManager, e.g. StandardMavenManager
+func managerName(manager MavenManager) string {
+	return reflect.TypeOf(manager).Name()
+}
+
+// arch returns the architecture dependencies are resolved for
+func arch() string {
+	if a, ok := os.LookupEnv("BP_ARCH"); ok {
+		return a
+	}
+	return runtime.GOARCH
+}
@@ -3,6 +3,7 @@ package maven_test
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -144,6 +145,45 @@ func testMavenManager(t *testing.T, context spec.G, it spec.S) {
 				Expect(layerContrib.(maven.Distribution)).ToNot(BeNil())
 			})
 		})
+
+		context("the POM uses model version 4.1.0", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte(`<project xmlns="http://maven.apache.org/POM/4.1.0">
+  <modelVersion>4.1.0</modelVersion>
+</project>`), 0644)).To(Succeed())
+
+				mavenManager = maven.NewStandardMavenManager(
+					ctx.Application.Path,
+					libpak.ConfigurationResolver{
+						Configurations: []libpak.BuildpackConfiguration{
+							{Default: "3", Name: "BP_MAVEN_VERSION"},
+						},
+					},
+					libpak.DependencyResolver{
+						Dependencies: []libpak.BuildpackDependency{dep3, dep4},
+						StackID:      "test-stack",
+					},
+					dc,
+					"/layers",
+					bard.NewLogger(io.Discard))
+			})
+
+			it("installs Maven 4", func() {
+				_, _, be, err := mavenManager.Install()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(be.Metadata["version"]).To(Equal("4.4.4"))
+			})
+
+			it("installs the version the user sets", func() {
+				t.Setenv("BP_MAVEN_VERSION", "3")
+
+				_, _, be, err := mavenManager.Install()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(be.Metadata["version"]).To(Equal("3.3.3"))
+			})
+		})
 	})
 
 	context("DaemonMavenManager", func() {
@@ -165,6 +205,7 @@ func testMavenManager(t *testing.T, context spec.G, it spec.S) {
 			}
 
 			mavenManager = maven.NewDaemonMavenManager(
+				ctx.Application.Path,
 				libpak.ConfigurationResolver{
 					Configurations: []libpak.BuildpackConfiguration{cfg},
 				},
@@ -195,6 +236,89 @@ func testMavenManager(t *testing.T, context spec.G, it spec.S) {
 			Expect(layerContrib.Name()).To(Equal("mvnd"))
 			Expect(layerContrib.(maven.MvndDistribution)).ToNot(BeNil())
 		})
+
+		it("configures mvnd properties", func() {
+			t.Setenv("BP_MAVEN_DAEMON_THREADS", "4")
+			t.Setenv("BP_MAVEN_DAEMON_MAX_HEAP_SIZE", "2g")
+			t.Setenv("BP_MAVEN_DAEMON_NO_DAEMON", "true")
+
+			_, layerContrib, _, err := mavenManager.Install()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(layerContrib.(maven.MvndDistribution).Properties).To(Equal(map[string]string{
+				"mvnd.threads":     "4",
+				"mvnd.maxHeapSize": "2g",
+				"mvnd.noDaemon":    "true",
+			}))
+		})
+
+		context("mvnd is not available for the architecture", func() {
+			var out *bytes.Buffer
+
+			it.Before(func() {
+				t.Setenv("BP_ARCH", "s390x")
+				out = &bytes.Buffer{}
+
+				mavenManager = maven.NewDaemonMavenManager(
+					ctx.Application.Path,
+					libpak.ConfigurationResolver{
+						Configurations: []libpak.BuildpackConfiguration{cfg},
+					},
+					libpak.DependencyResolver{
+						Dependencies: []libpak.BuildpackDependency{{
+							ID:      "mvnd",
+							Version: "1.1.1",
+							PURL:    "pkg:generic/apache-mvnd@1.1.1?arch=amd64",
+						}},
+						StackID: "test-stack",
+					},
+					libpak.DependencyCache{CachePath: "testdata"},
+					"/layers",
+					bard.NewLogger(out))
+			})
+
+			it("falls back with a warning", func() {
+				Expect(mavenManager.ShouldInstall()).To(BeFalse())
+				Expect(out.String()).To(ContainSubstring("WARNING: maven daemon (mvnd) is not available on s390x, falling back to Maven"))
+			})
+		})
+
+		context("BP_MAVEN_DAEMON_ENABLED is auto", func() {
+			it.Before(func() {
+				t.Setenv("BP_MAVEN_DAEMON_ENABLED", "auto")
+			})
+
+			it("does not use the daemon for a small reactor", func() {
+				out := &bytes.Buffer{}
+				Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte(`<project>
+  <modules>
+    <module>core</module>
+  </modules>
+</project>`), 0644)).To(Succeed())
+
+				mavenManager = maven.NewDaemonMavenManager(
+					ctx.Application.Path,
+					libpak.ConfigurationResolver{},
+					libpak.DependencyResolver{},
+					libpak.DependencyCache{},
+					"/layers",
+					bard.NewLogger(out))
+
+				Expect(mavenManager.ShouldInstall()).To(BeFalse())
+				Expect(out.String()).To(MatchRegexp(`Not using the Maven Daemon for 2 projects(, the build container has no CPU quota| and \d+ CPUs, it requires at least 10 projects and 4 CPUs)`))
+			})
+
+			it("uses the daemon for a large reactor on several CPUs", func() {
+				Expect(maven.UseDaemon(10, maven.ContainerResources{CPUs: 4, CPULimited: true})).To(BeTrue())
+				Expect(maven.UseDaemon(25, maven.ContainerResources{CPUs: 16, CPULimited: true})).To(BeTrue())
+				Expect(maven.UseDaemon(9, maven.ContainerResources{CPUs: 16, CPULimited: true})).To(BeFalse())
+				Expect(maven.UseDaemon(25, maven.ContainerResources{CPUs: 2, CPULimited: true})).To(BeFalse())
+			})
+
+			it("does not use the daemon without a CPU quota", func() {
+				Expect(maven.UseDaemon(25, maven.ContainerResources{CPUs: 16})).To(BeFalse())
+			})
+		})
 	})
 
 	context("WrapperMavenManager", func() {
@@ -228,6 +352,19 @@ func testMavenManager(t *testing.T, context spec.G, it spec.S) {
 			Expect(fi.Mode()).To(BeEquivalentTo(0755))
 		})
 
+		it("reads the Maven version from the wrapper properties", func() {
+			Expect(os.WriteFile(filepath.Join(mvnwPropsPath, "maven-wrapper.properties"), []byte(
+				"wrapperVersion=3.3.2\r\n"+
+					"distributionUrl=https://repo.maven.apache.org/maven2/org/apache/maven/apache-maven/3.9.6/apache-maven-3.9.6-bin.zip\r\n",
+			), 0644)).To(Succeed())
+
+			Expect(maven.NewWrapperMavenManager(ctx.Application.Path, bard.NewLogger(io.Discard)).Version()).To(Equal("3.9.6"))
+		})
+
+		it("returns no version without wrapper properties", func() {
+			Expect(maven.NewWrapperMavenManager(ctx.Application.Path, bard.NewLogger(io.Discard)).Version()).To(BeEmpty())
+		})
+
 		it("proceeds without error if mvnw could not have been made executable", func() {
 			if _, err := os.Stat("/dev/null"); errors.Is(err, os.ErrNotExist) {
 				t.Skip("No /dev/null thus not a unix system. Skipping chmod test.")
@@ -299,7 +436,7 @@ func testMavenManager(t *testing.T, context spec.G, it spec.S) {
 
 			mvnFilePath = filepath.Join(addToPath, "mvn")
 
-			mavenManager = maven.NewNoopMavenManager(bard.NewLogger(io.Discard))
+			mavenManager = maven.NewNoopMavenManager(libpak.ConfigurationResolver{}, bard.NewLogger(io.Discard))
 		})
 
 		it("should install", func() {
@@ -325,5 +462,101 @@ func testMavenManager(t *testing.T, context spec.G, it spec.S) {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(fi.Mode()).To(BeEquivalentTo(0755))
 		})
+
+		context("MAVEN_HOME points at an installation", func() {
+			var home string
+
+			it.Before(func() {
+				var err error
+				home, err = os.MkdirTemp("", "maven-home")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(os.MkdirAll(filepath.Join(home, "bin"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(home, "bin", "mvn"), []byte{}, 0755)).To(Succeed())
+				Expect(os.MkdirAll(filepath.Join(home, "lib"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(home, "lib", "maven-core-3.9.9.jar"), []byte{}, 0644)).To(Succeed())
+
+				t.Setenv("MAVEN_HOME", home)
+			})
+
+			it.After(func() {
+				Expect(os.RemoveAll(home)).To(Succeed())
+			})
+
+			it("should install", func() {
+				Expect(mavenManager.ShouldInstall()).To(BeTrue())
+			})
+
+			it("uses the installation and records it in the BOM", func() {
+				cmd, layerContrib, be, err := mavenManager.Install()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(cmd).To(Equal(filepath.Join(home, "bin", "mvn")))
+				Expect(layerContrib).To(BeNil())
+				Expect(be.Name).To(Equal("maven"))
+				Expect(be.Build).To(BeTrue())
+				Expect(be.Metadata["version"]).To(Equal("3.9.9"))
+				Expect(be.Metadata["path"]).To(Equal(home))
+			})
+
+			it("uses M2_HOME", func() {
+				t.Setenv("MAVEN_HOME", "")
+				t.Setenv("M2_HOME", home)
+
+				cmd, _, _, err := mavenManager.Install()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cmd).To(Equal(filepath.Join(home, "bin", "mvn")))
+			})
+
+			it("accepts a matching BP_MAVEN_VERSION", func() {
+				t.Setenv("BP_MAVEN_VERSION", "3.9")
+
+				_, _, _, err := mavenManager.Install()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			it("rejects a different BP_MAVEN_VERSION", func() {
+				t.Setenv("BP_MAVEN_VERSION", "4")
+
+				_, _, _, err := mavenManager.Install()
+				Expect(err).To(MatchError(fmt.Sprintf("installed Maven 3.9.9 at %s does not match $BP_MAVEN_VERSION 4, "+
+					"unset $MAVEN_HOME and $M2_HOME or $BP_MAVEN_VERSION", home)))
+			})
+
+			it("is not replaced by the standard distribution", func() {
+				Expect(maven.NewStandardMavenManager(
+					ctx.Application.Path,
+					libpak.ConfigurationResolver{},
+					libpak.DependencyResolver{},
+					libpak.DependencyCache{},
+					"/layers",
+					bard.NewLogger(io.Discard)).ShouldInstall()).To(BeFalse())
+			})
+
+			it("is replaced by the standard distribution in the version BP_MAVEN_VERSION requests", func() {
+				t.Setenv("BP_MAVEN_VERSION", "4")
+
+				Expect(maven.NewStandardMavenManager(
+					ctx.Application.Path,
+					libpak.ConfigurationResolver{},
+					libpak.DependencyResolver{},
+					libpak.DependencyCache{},
+					"/layers",
+					bard.NewLogger(io.Discard)).ShouldInstall()).To(BeTrue())
+			})
+
+			it("is not replaced by the standard distribution in the version BP_MAVEN_VERSION requests with a wrapper", func() {
+				t.Setenv("BP_MAVEN_VERSION", "4")
+				Expect(os.WriteFile(mvnwFilepath, []byte{}, 0755)).To(Succeed())
+
+				Expect(maven.NewStandardMavenManager(
+					ctx.Application.Path,
+					libpak.ConfigurationResolver{},
+					libpak.DependencyResolver{},
+					libpak.DependencyCache{},
+					"/layers",
+					bard.NewLogger(io.Discard)).ShouldInstall()).To(BeFalse())
+			})
+		})
 	})
 }
@@ -17,22 +17,31 @@
 package maven
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 
 	"github.com/buildpacks/libcnb"
 	"github.com/paketo-buildpacks/libpak"
 	"github.com/paketo-buildpacks/libpak/bard"
 	"github.com/paketo-buildpacks/libpak/crush"
+	"github.com/paketo-buildpacks/libpak/effect"
 )
 
+// mvndPropertiesHeader marks an mvnd.properties file as written by the buildpack, rather than the distribution
+const mvndPropertiesHeader = "# Generated by the Paketo Buildpack for Maven\n"
+
 type MvndDistribution struct {
 	LayerContributor libpak.DependencyLayerContributor
 	Logger           bard.Logger
+	Properties       map[string]string
 }
 
 func NewMvndDistribution(dependency libpak.BuildpackDependency, cache libpak.DependencyCache) (MvndDistribution, libcnb.BOMEntry) {
 	contributor, entry := libpak.NewDependencyLayer(dependency, cache, libcnb.LayerTypes{
+		Build: true,
 		Cache: true,
 	})
 	return MvndDistribution{LayerContributor: contributor}, entry
@@ -41,7 +50,7 @@ func NewMvndDistribution(dependency libpak.BuildpackDependency, cache libpak.Dep
 func (d MvndDistribution) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
 	d.LayerContributor.Logger = d.Logger
 
-	return d.LayerContributor.Contribute(layer, func(artifact *os.File) (libcnb.Layer, error) {
+	layer, err := d.LayerContributor.Contribute(layer, func(artifact *os.File) (libcnb.Layer, error) {
 		d.Logger.Bodyf("Expanding to %s", layer.Path)
 		if err := crush.ExtractZip(artifact, layer.Path, 1); err != nil {
 			return libcnb.Layer{}, fmt.Errorf("unable to expand Maven\n%w", err)
@@ -49,8 +58,79 @@ func (d MvndDistribution) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
 
 		return layer, nil
 	})
+	if err != nil {
+		return libcnb.Layer{}, err
+	}
+
+	// the layer is cached, so the properties are written on every build rather than only when it is expanded
+	if err := d.writeProperties(filepath.Join(layer.Path, "conf", "mvnd.properties")); err != nil {
+		return libcnb.Layer{}, fmt.Errorf("unable to write mvnd.properties\n%w", err)
+	}
+
+	for k, v := range MavenEnvironment(filepath.Join(layer.Path, "mvn")) {
+		layer.BuildEnvironment.Override(k, v)
+	}
+
+	return layer, nil
+}
+
+// MavenHome returns where the Maven embedded in the distribution is installed
+func (d MvndDistribution) MavenHome(layersPath string) string {
+	return filepath.Join(layersPath, d.Name(), "mvn")
+}
+
+func (d MvndDistribution) writeProperties(file string) error {
+	if len(d.Properties) == 0 {
+		// only remove a file written by a previous build, the distribution may ship its own
+		if b, err := os.ReadFile(file); err == nil && bytes.HasPrefix(b, []byte(mvndPropertiesHeader)) {
+			return os.Remove(file)
+		}
+		return nil
+	}
+
+	var keys []string
+	for k := range d.Properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b bytes.Buffer
+	b.WriteString(mvndPropertiesHeader)
+	for _, k := range keys {
+		d.Logger.Bodyf("Setting %s=%s", k, d.Properties[k])
+		fmt.Fprintf(&b, "%s=%s\n", k, d.Properties[k])
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, b.Bytes(), 0644)
 }
 
 func (d MvndDistribution) Name() string {
 	return d.LayerContributor.LayerName()
 }
+
+// MvndStoppingExecutor stops any Maven daemons once the build has run, whether or not it succeeded
+type MvndStoppingExecutor struct {
+	Delegate effect.Executor
+	Logger   bard.Logger
+}
+
+func (m MvndStoppingExecutor) Execute(execution effect.Execution) error {
+	defer func() {
+		m.Logger.Body("Stopping Maven daemon")
+		if err := m.Delegate.Execute(effect.Execution{
+			Command: execution.Command,
+			Args:    []string{"--stop"},
+			Dir:     execution.Dir,
+			Env:     execution.Env,
+			Stdout:  execution.Stdout,
+			Stderr:  execution.Stderr,
+		}); err != nil {
+			m.Logger.Bodyf("WARNING: unable to stop Maven daemon\n%s", err)
+		}
+	}()
+
+	return m.Delegate.Execute(execution)
+}
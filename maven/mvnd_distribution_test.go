@@ -17,6 +17,7 @@
 package maven_test
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -24,6 +25,7 @@ import (
 	"github.com/buildpacks/libcnb"
 	. "github.com/onsi/gomega"
 	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/effect"
 	"github.com/sclevine/spec"
 
 	"github.com/paketo-buildpacks/maven/v6/maven"
@@ -63,6 +65,103 @@ func testMvndDistribution(t *testing.T, context spec.G, it spec.S) {
 
 		Expect(layer.Cache).To(BeTrue())
 		Expect(filepath.Join(layer.Path, "fixture-marker")).To(BeARegularFile())
+		Expect(filepath.Join(layer.Path, "conf", "mvnd.properties")).NotTo(BeAnExistingFile())
 	})
 
+	it("exposes the embedded Maven to the build", func() {
+		dep := libpak.BuildpackDependency{
+			URI:    "https://localhost/stub-mvnd-distribution.zip",
+			SHA256: "75458bf0354fde2c9762366e7d952489587e9d618630100b432a5486c4d22664",
+		}
+		dc := libpak.DependencyCache{CachePath: "testdata"}
+
+		d, _ := maven.NewMvndDistribution(dep, dc)
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err = d.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(layer.Build).To(BeTrue())
+		Expect(layer.BuildEnvironment["MAVEN_HOME.override"]).To(Equal(filepath.Join(layer.Path, "mvn")))
+		Expect(layer.BuildEnvironment["M2_HOME.override"]).To(Equal(filepath.Join(layer.Path, "mvn")))
+		Expect(layer.BuildEnvironment["MAVEN_REPO_LOCAL.override"]).To(HaveSuffix(filepath.Join(".m2", "repository")))
+		Expect(d.MavenHome("/layers")).To(Equal(filepath.Join("/layers", d.Name(), "mvn")))
+	})
+
+	context("mvnd properties are configured", func() {
+		var d maven.MvndDistribution
+
+		it.Before(func() {
+			dep := libpak.BuildpackDependency{
+				URI:    "https://localhost/stub-mvnd-distribution.zip",
+				SHA256: "75458bf0354fde2c9762366e7d952489587e9d618630100b432a5486c4d22664",
+			}
+			dc := libpak.DependencyCache{CachePath: "testdata"}
+
+			d, _ = maven.NewMvndDistribution(dep, dc)
+			d.Properties = map[string]string{"mvnd.threads": "4", "mvnd.maxHeapSize": "2g"}
+		})
+
+		it("writes mvnd.properties", func() {
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = d.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			b, err := os.ReadFile(filepath.Join(layer.Path, "conf", "mvnd.properties"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal("# Generated by the Paketo Buildpack for Maven\nmvnd.maxHeapSize=2g\nmvnd.threads=4\n"))
+		})
+
+		it("removes mvnd.properties from a previous build when no longer configured", func() {
+			layer, err := ctx.Layers.Layer("test-layer")
+			Expect(err).NotTo(HaveOccurred())
+
+			layer, err = d.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			d.Properties = nil
+			layer, err = d.Contribute(layer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(filepath.Join(layer.Path, "conf", "mvnd.properties")).NotTo(BeAnExistingFile())
+		})
+	})
+
+	context("MvndStoppingExecutor", func() {
+		it("stops the daemon after the build", func() {
+			executor := &RecordingExecutor{}
+
+			err := maven.MvndStoppingExecutor{Delegate: executor}.Execute(effect.Execution{Command: "/layers/mvnd/bin/mvnd", Args: []string{"package"}, Dir: "/workspace"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executor.Executions).To(HaveLen(2))
+			Expect(executor.Executions[0].Args).To(Equal([]string{"package"}))
+			Expect(executor.Executions[1].Command).To(Equal("/layers/mvnd/bin/mvnd"))
+			Expect(executor.Executions[1].Args).To(Equal([]string{"--stop"}))
+			Expect(executor.Executions[1].Dir).To(Equal("/workspace"))
+		})
+
+		it("stops the daemon when the build fails", func() {
+			executor := &RecordingExecutor{Err: fmt.Errorf("test-error")}
+
+			err := maven.MvndStoppingExecutor{Delegate: executor}.Execute(effect.Execution{Command: "/layers/mvnd/bin/mvnd", Args: []string{"package"}})
+			Expect(err).To(MatchError("test-error"))
+
+			Expect(executor.Executions).To(HaveLen(2))
+			Expect(executor.Executions[1].Args).To(Equal([]string{"--stop"}))
+		})
+	})
+}
+
+type RecordingExecutor struct {
+	Executions []effect.Execution
+	Err        error
+}
+
+func (r *RecordingExecutor) Execute(execution effect.Execution) error {
+	r.Executions = append(r.Executions, execution)
+	return r.Err
 }
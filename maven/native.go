@@ -0,0 +1,185 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/effect"
+)
+
+const (
+	// NativeGroupID and NativeArtifactID are the coordinates of the GraalVM Native Build Tools plugin
+	NativeGroupID    = "org.graalvm.buildtools"
+	NativeArtifactID = "native-maven-plugin"
+
+	// NativeProfile is the profile conventionally used to build a native image
+	NativeProfile = "native"
+
+	// NativeGoal builds a native image in the running Maven build, after the application is packaged
+	NativeGoal = "native:compile-no-fork"
+
+	// NativeArchive is the archive the native executable is put in, so that it is restored as the application
+	NativeArchive = "native-image.zip"
+)
+
+// NativeImageRequested determines if Maven should build a native image.  $BP_NATIVE_IMAGE requests it too, but as the
+// Native Image buildpack also builds a native image when it is set, $BP_MAVEN_NATIVE_IMAGE takes precedence so that
+// setting it to false leaves the native image to that buildpack.
+func NativeImageRequested(configResolver libpak.ConfigurationResolver) bool {
+	if _, ok := configResolver.Resolve("BP_MAVEN_NATIVE_IMAGE"); ok {
+		return configResolver.ResolveBool("BP_MAVEN_NATIVE_IMAGE")
+	}
+	return configResolver.ResolveBool("BP_NATIVE_IMAGE")
+}
+
+// IsNative determines if the POM is configured to build a native image
+func (p POM) IsNative() bool {
+	return p.HasPlugin(NativeGroupID, NativeArtifactID) || p.HasProfile(NativeProfile)
+}
+
+// NativeArguments returns the arguments to build a native image of the project.  The native profile is activated if
+// the POM has one, as it configures the plugin and its goals.  Otherwise the native goal is run after the arguments.
+func NativeArguments(pom POM, args []string) []string {
+	if pom.HasProfile(NativeProfile) {
+		if contains(activeProfiles(args), []string{NativeProfile}) {
+			return args
+		}
+		return append(args, fmt.Sprintf("-P%s", NativeProfile))
+	}
+
+	if contains(args, []string{NativeGoal, "native:compile"}) {
+		return args
+	}
+	return append(args, NativeGoal)
+}
+
+var elfMagic = []byte{0x7f, 'E', 'L', 'F'}
+
+// NativeExecutableDetector is an implementation of libbs.InterestingFileDetector that returns true if the path is an
+// executable ELF file, as built by native-image
+type NativeExecutableDetector struct{}
+
+func (NativeExecutableDetector) Interesting(path string) (bool, error) {
+	s, err := os.Stat(path)
+	if err != nil {
+		return false, fmt.Errorf("unable to stat %s\n%w", path, err)
+	}
+	if !s.Mode().IsRegular() || s.Mode().Perm()&0111 == 0 {
+		return false, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("unable to open %s\n%w", path, err)
+	}
+	defer f.Close()
+
+	magic := make([]byte, len(elfMagic))
+	if _, err := io.ReadFull(f, magic); err == io.EOF || err == io.ErrUnexpectedEOF {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("unable to read %s\n%w", path, err)
+	}
+
+	return bytes.Equal(magic, elfMagic), nil
+}
+
+// NativeArchivingExecutor is an implementation of effect.Executor that puts the native executable built by Maven in
+// Directory into a NativeArchive.  The application is restored from a single built artifact by extracting it, which
+// the executable itself cannot be.
+type NativeArchivingExecutor struct {
+	Delegate  effect.Executor
+	Directory string
+	Logger    bard.Logger
+}
+
+func (n NativeArchivingExecutor) Execute(execution effect.Execution) error {
+	if err := n.Delegate.Execute(execution); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(n.Directory)
+	if err != nil {
+		return fmt.Errorf("unable to list %s\n%w", n.Directory, err)
+	}
+
+	var executables []string
+	for _, e := range entries {
+		file := filepath.Join(n.Directory, e.Name())
+		if ok, err := (NativeExecutableDetector{}).Interesting(file); err != nil {
+			return fmt.Errorf("unable to investigate %s\n%w", file, err)
+		} else if ok {
+			executables = append(executables, file)
+		}
+	}
+
+	if len(executables) != 1 {
+		return fmt.Errorf("unable to find a single native executable in %s, candidates: %s", n.Directory,
+			strings.Join(executables, ", "))
+	}
+
+	n.Logger.Bodyf("Found native executable %s", executables[0])
+	return archiveExecutable(executables[0], filepath.Join(n.Directory, NativeArchive))
+}
+
+func archiveExecutable(source string, destination string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return fmt.Errorf("unable to open %s\n%w", source, err)
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return fmt.Errorf("unable to stat %s\n%w", source, err)
+	}
+
+	out, err := os.Create(destination)
+	if err != nil {
+		return fmt.Errorf("unable to create %s\n%w", destination, err)
+	}
+	defer out.Close()
+
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return fmt.Errorf("unable to create header for %s\n%w", source, err)
+	}
+	header.Method = zip.Deflate
+
+	w := zip.NewWriter(out)
+	entry, err := w.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("unable to add %s to %s\n%w", source, destination, err)
+	}
+	if _, err := io.Copy(entry, in); err != nil {
+		return fmt.Errorf("unable to write %s to %s\n%w", source, destination, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("unable to close %s\n%w", destination, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven_test
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/maven/v6/maven"
+)
+
+func testNative(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		dir string
+		elf = []byte{0x7f, 'E', 'L', 'F', 0x02, 0x01}
+	)
+
+	it.Before(func() {
+		var err error
+
+		dir, err = os.MkdirTemp("", "native")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	it("recognizes a POM configured to build a native image", func() {
+		Expect(maven.POM{}.IsNative()).To(BeFalse())
+		Expect(maven.POM{Plugins: []maven.Plugin{{GroupID: "org.graalvm.buildtools", ArtifactID: "native-maven-plugin"}}}.IsNative()).To(BeTrue())
+		Expect(maven.POM{Profiles: []maven.Profile{{ID: "native"}}}.IsNative()).To(BeTrue())
+	})
+
+	it("activates the native profile unless it is already active", func() {
+		pom := maven.POM{Profiles: []maven.Profile{{ID: "native"}}}
+
+		Expect(maven.NativeArguments(pom, []string{"package"})).To(Equal([]string{"package", "-Pnative"}))
+		Expect(maven.NativeArguments(pom, []string{"package", "-P", "prod,native"})).To(Equal([]string{"package", "-P", "prod,native"}))
+	})
+
+	it("runs the native goal unless it is already run", func() {
+		pom := maven.POM{Plugins: []maven.Plugin{{GroupID: "org.graalvm.buildtools", ArtifactID: "native-maven-plugin"}}}
+
+		Expect(maven.NativeArguments(pom, []string{"package"})).To(Equal([]string{"package", "native:compile-no-fork"}))
+		Expect(maven.NativeArguments(pom, []string{"package", "native:compile"})).To(Equal([]string{"package", "native:compile"}))
+	})
+
+	it("detects native executables", func() {
+		Expect(os.WriteFile(filepath.Join(dir, "app"), elf, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "lib.so"), elf, 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "run.sh"), []byte("#!/bin/sh"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "empty"), []byte{}, 0755)).To(Succeed())
+
+		Expect(maven.NativeExecutableDetector{}.Interesting(filepath.Join(dir, "app"))).To(BeTrue())
+		Expect(maven.NativeExecutableDetector{}.Interesting(filepath.Join(dir, "lib.so"))).To(BeFalse())
+		Expect(maven.NativeExecutableDetector{}.Interesting(filepath.Join(dir, "run.sh"))).To(BeFalse())
+		Expect(maven.NativeExecutableDetector{}.Interesting(filepath.Join(dir, "empty"))).To(BeFalse())
+		Expect(maven.NativeExecutableDetector{}.Interesting(dir)).To(BeFalse())
+	})
+
+	it("archives the native executable after a successful build", func() {
+		Expect(os.WriteFile(filepath.Join(dir, "app"), elf, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "app.jar"), []byte{}, 0644)).To(Succeed())
+
+		err := maven.NativeArchivingExecutor{Delegate: &RecordingExecutor{}, Directory: dir}.Execute(effect.Execution{})
+		Expect(err).NotTo(HaveOccurred())
+
+		r, err := zip.OpenReader(filepath.Join(dir, "native-image.zip"))
+		Expect(err).NotTo(HaveOccurred())
+		defer r.Close()
+
+		Expect(r.File).To(HaveLen(1))
+		Expect(r.File[0].Name).To(Equal("app"))
+		Expect(r.File[0].Mode().Perm()).To(Equal(os.FileMode(0755)))
+
+		in, err := r.File[0].Open()
+		Expect(err).NotTo(HaveOccurred())
+		defer in.Close()
+		Expect(io.ReadAll(in)).To(Equal(elf))
+	})
+
+	it("fails without a single native executable", func() {
+		err := maven.NativeArchivingExecutor{Delegate: &RecordingExecutor{}, Directory: dir}.Execute(effect.Execution{})
+		Expect(err).To(MatchError(ContainSubstring("unable to find a single native executable")))
+	})
+
+	it("does not archive when the build fails", func() {
+		Expect(os.WriteFile(filepath.Join(dir, "app"), elf, 0755)).To(Succeed())
+
+		err := maven.NativeArchivingExecutor{Delegate: &RecordingExecutor{Err: fmt.Errorf("test-error")}, Directory: dir}.
+			Execute(effect.Execution{})
+		Expect(err).To(MatchError("test-error"))
+
+		Expect(filepath.Join(dir, "native-image.zip")).NotTo(BeAnExistingFile())
+	})
+}
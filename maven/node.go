@@ -0,0 +1,148 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak"
+)
+
+// NodeProject is a JavaScript project that is built with the application
+type NodeProject struct {
+	// Directory is the directory of the project
+	Directory string
+
+	// PackageManager is the package manager of the project, npm, yarn or pnpm
+	PackageManager string
+
+	// PackageManagerVersion is the version of the package manager in the packageManager field of package.json, if any
+	PackageManagerVersion string
+
+	// NodeVersion is the version of Node the project requires, if any, read from NodeVersionSource
+	NodeVersion       string
+	NodeVersionSource string
+}
+
+// lockfiles are the lockfiles of each package manager, in order of precedence
+var lockfiles = []struct {
+	Name           string
+	PackageManager string
+}{
+	{"pnpm-lock.yaml", PackageManagerPNPM},
+	{"yarn.lock", PackageManagerYarn},
+	{"package-lock.json", PackageManagerNPM},
+	{"package.json", PackageManagerNPM},
+}
+
+// packageJSON is the part of a package.json the buildpack needs to understand
+type packageJSON struct {
+	Engines struct {
+		Node string `json:"node"`
+	} `json:"engines"`
+	PackageManager string `json:"packageManager"`
+}
+
+// NodeProjectPaths returns the directories, relative to the application, to look for JavaScript projects in.  They are
+// read from $BP_NODE_PROJECT_PATHS, separated by commas or spaces, or else $BP_NODE_PROJECT_PATH.  The application
+// itself is used if neither is set.
+func NodeProjectPaths(configResolver libpak.ConfigurationResolver) []string {
+	if s, _ := configResolver.Resolve("BP_NODE_PROJECT_PATHS"); strings.TrimSpace(s) != "" {
+		return strings.FieldsFunc(s, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+	}
+	if s, _ := configResolver.Resolve("BP_NODE_PROJECT_PATH"); s != "" {
+		return []string{s}
+	}
+	return []string{"."}
+}
+
+// ReadNodeProject reads the JavaScript project in dir.  It returns false if dir has neither a package.json nor a
+// lockfile.
+func ReadNodeProject(dir string) (NodeProject, bool, error) {
+	project := NodeProject{Directory: dir}
+
+	for _, l := range lockfiles {
+		file := filepath.Join(dir, l.Name)
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return NodeProject{}, false, fmt.Errorf("unable to determine if file %s exists\n%w", file, err)
+		}
+		project.PackageManager = l.PackageManager
+		break
+	}
+	if project.PackageManager == "" {
+		return NodeProject{}, false, nil
+	}
+
+	var p packageJSON
+	file := filepath.Join(dir, "package.json")
+	if b, err := os.ReadFile(file); err == nil {
+		// a package.json that cannot be parsed configures no versions, it is reported by the build of the project
+		_ = json.Unmarshal(b, &p)
+	} else if !os.IsNotExist(err) {
+		return NodeProject{}, false, fmt.Errorf("unable to read %s\n%w", file, err)
+	}
+
+	if name, version, ok := strings.Cut(p.PackageManager, "@"); ok && name == project.PackageManager {
+		// the version can be followed by a hash, e.g. pnpm@9.0.0+sha512.abc
+		project.PackageManagerVersion, _, _ = strings.Cut(version, "+")
+	}
+
+	file = filepath.Join(dir, ".nvmrc")
+	if b, err := os.ReadFile(file); err == nil {
+		if v := strings.TrimPrefix(strings.TrimSpace(string(b)), "v"); v != "" && !strings.HasPrefix(v, "lts/") && v != "node" {
+			project.NodeVersion, project.NodeVersionSource = v, ".nvmrc"
+		}
+	} else if !os.IsNotExist(err) {
+		return NodeProject{}, false, fmt.Errorf("unable to read %s\n%w", file, err)
+	}
+	if project.NodeVersion == "" && strings.TrimSpace(p.Engines.Node) != "" {
+		project.NodeVersion, project.NodeVersionSource = strings.TrimSpace(p.Engines.Node), "package.json"
+	}
+
+	return project, true, nil
+}
+
+// Requires returns the build plan requirements to build the project, the package manager, if it is not npm, and Node
+func (n NodeProject) Requires() []libcnb.BuildPlanRequire {
+	var requires []libcnb.BuildPlanRequire
+
+	if name := map[string]string{PackageManagerYarn: PlanEntryYarn, PackageManagerPNPM: PlanEntryPnpm}[n.PackageManager]; name != "" {
+		metadata := map[string]interface{}{"build": true}
+		if n.PackageManagerVersion != "" {
+			metadata["version"] = n.PackageManagerVersion
+			metadata["version-source"] = "package.json"
+		}
+		requires = append(requires, libcnb.BuildPlanRequire{Name: name, Metadata: metadata})
+	}
+
+	metadata := map[string]interface{}{"build": true}
+	if n.NodeVersion != "" {
+		metadata["version"] = n.NodeVersion
+		metadata["version-source"] = n.NodeVersionSource
+	}
+	requires = append(requires, libcnb.BuildPlanRequire{Name: PlanEntryNode, Metadata: metadata})
+
+	return requires
+}
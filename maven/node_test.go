@@ -0,0 +1,113 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/maven/v6/maven"
+)
+
+func testNode(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		dir string
+	)
+
+	it.Before(func() {
+		var err error
+
+		dir, err = os.MkdirTemp("", "node")
+		Expect(err).NotTo(HaveOccurred())
+
+		t.Setenv("BP_NODE_PROJECT_PATH", "")
+		t.Setenv("BP_NODE_PROJECT_PATHS", "")
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	it("reads the project paths", func() {
+		Expect(maven.NodeProjectPaths(libpak.ConfigurationResolver{})).To(Equal([]string{"."}))
+
+		t.Setenv("BP_NODE_PROJECT_PATH", "frontend")
+		Expect(maven.NodeProjectPaths(libpak.ConfigurationResolver{})).To(Equal([]string{"frontend"}))
+
+		t.Setenv("BP_NODE_PROJECT_PATHS", "admin, shop  docs")
+		Expect(maven.NodeProjectPaths(libpak.ConfigurationResolver{})).To(Equal([]string{"admin", "shop", "docs"}))
+	})
+
+	it("does not read a directory without a project", func() {
+		_, ok, err := maven.ReadNodeProject(dir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeFalse())
+	})
+
+	it("reads an npm project", func() {
+		Expect(os.WriteFile(filepath.Join(dir, "package-lock.json"), []byte{}, 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"engines": {"node": ">=20"}}`), 0644)).To(Succeed())
+
+		project, ok, err := maven.ReadNodeProject(dir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeTrue())
+
+		Expect(project.Requires()).To(Equal([]libcnb.BuildPlanRequire{
+			{Name: "node", Metadata: map[string]interface{}{"build": true, "version": ">=20", "version-source": "package.json"}},
+		}))
+	})
+
+	it("reads a pnpm project", func() {
+		Expect(os.WriteFile(filepath.Join(dir, "pnpm-lock.yaml"), []byte{}, 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{
+  "engines": {"node": ">=20"},
+  "packageManager": "pnpm@9.1.0+sha512.abc"
+}`), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, ".nvmrc"), []byte("v22.2.0\n"), 0644)).To(Succeed())
+
+		project, ok, err := maven.ReadNodeProject(dir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeTrue())
+
+		Expect(project.Requires()).To(Equal([]libcnb.BuildPlanRequire{
+			{Name: "pnpm", Metadata: map[string]interface{}{"build": true, "version": "9.1.0", "version-source": "package.json"}},
+			{Name: "node", Metadata: map[string]interface{}{"build": true, "version": "22.2.0", "version-source": ".nvmrc"}},
+		}))
+	})
+
+	it("reads a yarn project without versions", func() {
+		Expect(os.WriteFile(filepath.Join(dir, "yarn.lock"), []byte{}, 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, ".nvmrc"), []byte("lts/*\n"), 0644)).To(Succeed())
+
+		project, ok, err := maven.ReadNodeProject(dir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeTrue())
+
+		Expect(project.Requires()).To(Equal([]libcnb.BuildPlanRequire{
+			{Name: "yarn", Metadata: map[string]interface{}{"build": true}},
+			{Name: "node", Metadata: map[string]interface{}{"build": true}},
+		}))
+	})
+}
@@ -0,0 +1,66 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// LineWriter passes output through to a delegate writer, calling an observer with each complete line of output with
+// any colors removed
+type LineWriter struct {
+	delegate io.Writer
+	observer func(line string)
+	buffer   bytes.Buffer
+}
+
+func NewLineWriter(delegate io.Writer, observer func(line string)) *LineWriter {
+	if delegate == nil {
+		delegate = io.Discard
+	}
+	return &LineWriter{delegate: delegate, observer: observer}
+}
+
+func (l *LineWriter) Write(p []byte) (int, error) {
+	l.buffer.Write(p)
+	for {
+		i := bytes.IndexByte(l.buffer.Bytes(), '\n')
+		if i < 0 {
+			break
+		}
+		l.observe(string(l.buffer.Next(i + 1)))
+	}
+
+	return l.delegate.Write(p)
+}
+
+// Flush observes any trailing output not terminated by a newline
+func (l *LineWriter) Flush() {
+	if l.buffer.Len() > 0 {
+		l.observe(l.buffer.String())
+		l.buffer.Reset()
+	}
+}
+
+func (l *LineWriter) observe(line string) {
+	l.observer(strings.TrimSpace(ansiEscape.ReplaceAllString(line, "")))
+}
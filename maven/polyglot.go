@@ -0,0 +1,89 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PolyglotGroupID is the group of the Polyglot Maven extensions
+const PolyglotGroupID = "io.takari.polyglot"
+
+// PolyglotPOMs maps the POM files supported by Polyglot Maven, in order of precedence, to the extension that reads them
+var PolyglotPOMs = []struct {
+	File       string
+	ArtifactID string
+}{
+	{"pom.yaml", "polyglot-yaml"},
+	{"pom.yml", "polyglot-yaml"},
+	{"pom.groovy", "polyglot-groovy"},
+	{"pom.kts", "polyglot-kotlin"},
+	{"pom.scala", "polyglot-scala"},
+	{"pom.rb", "polyglot-ruby"},
+	{"pom.clj", "polyglot-clojure"},
+	{"pom.atom", "polyglot-atom"},
+	{"pom.java", "polyglot-java"},
+}
+
+// FindPolyglotPOM returns the Polyglot Maven POM of the project at path whose extension is declared in
+// .mvn/extensions.xml.  A POM without its extension is ignored, as Maven would not be able to read it.
+func FindPolyglotPOM(path string) (string, bool, error) {
+	extensions, ok, err := ReadExtensions(filepath.Join(path, ".mvn", "extensions.xml"))
+	if err != nil || !ok {
+		return "", false, err
+	}
+
+	declared := map[string]bool{}
+	for _, e := range extensions.Extensions {
+		if strings.TrimSpace(e.GroupID) == PolyglotGroupID {
+			declared[strings.TrimSpace(e.ArtifactID)] = true
+		}
+	}
+
+	for _, p := range PolyglotPOMs {
+		if !declared[p.ArtifactID] {
+			continue
+		}
+
+		file := filepath.Join(path, p.File)
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return "", false, fmt.Errorf("unable to determine if %s exists\n%w", file, err)
+		}
+
+		return p.File, true, nil
+	}
+
+	return "", false, nil
+}
+
+// isPOM determines if a file name is a POM, in XML or any of the Polyglot Maven formats
+func isPOM(name string) bool {
+	if name == "pom.xml" {
+		return true
+	}
+	for _, p := range PolyglotPOMs {
+		if p.File == name {
+			return true
+		}
+	}
+	return false
+}
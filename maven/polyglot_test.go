@@ -0,0 +1,82 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/maven/v6/maven"
+)
+
+func testPolyglot(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		path string
+	)
+
+	it.Before(func() {
+		var err error
+
+		path, err = os.MkdirTemp("", "polyglot")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Join(path, ".mvn"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, ".mvn", "extensions.xml"), []byte(`<extensions>
+  <extension>
+    <groupId>io.takari.polyglot</groupId>
+    <artifactId>polyglot-groovy</artifactId>
+    <version>0.7.0</version>
+  </extension>
+</extensions>`), 0644)).To(Succeed())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(path)).To(Succeed())
+	})
+
+	it("finds the POM of a declared extension", func() {
+		Expect(os.WriteFile(filepath.Join(path, "pom.groovy"), []byte{}, 0644)).To(Succeed())
+
+		file, ok, err := maven.FindPolyglotPOM(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeTrue())
+		Expect(file).To(Equal("pom.groovy"))
+	})
+
+	it("ignores the POM of an undeclared extension", func() {
+		Expect(os.WriteFile(filepath.Join(path, "pom.yaml"), []byte{}, 0644)).To(Succeed())
+
+		_, ok, err := maven.FindPolyglotPOM(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeFalse())
+	})
+
+	it("does not find a POM without extensions", func() {
+		Expect(os.RemoveAll(filepath.Join(path, ".mvn"))).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, "pom.groovy"), []byte{}, 0644)).To(Succeed())
+
+		_, ok, err := maven.FindPolyglotPOM(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeFalse())
+	})
+}
@@ -0,0 +1,383 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ModelVersion4 is the POM model version introduced by Maven 4
+const ModelVersion4 = "4.1.0"
+
+// POM is the part of a Maven project object model the buildpack needs to understand, in either the 4.0.0 or 4.1.0
+// model
+type POM struct {
+	XMLName      xml.Name   `xml:"project"`
+	Root         bool       `xml:"root,attr"`
+	ModelVersion string     `xml:"modelVersion"`
+	Parent       Parent     `xml:"parent"`
+	GroupID      string     `xml:"groupId"`
+	ArtifactID   string     `xml:"artifactId"`
+	Version      string     `xml:"version"`
+	Packaging    string     `xml:"packaging"`
+	Properties   Properties `xml:"properties"`
+	Modules      []string   `xml:"modules>module"`
+	Subprojects  []string   `xml:"subprojects>subproject"`
+	FinalName    string     `xml:"build>finalName"`
+	Plugins      []Plugin   `xml:"build>plugins>plugin"`
+	Profiles     []Profile  `xml:"profiles>profile"`
+}
+
+// Properties are the properties declared in a POM
+type Properties struct {
+	Entries []Property `xml:",any"`
+}
+
+// Property is a property declared in a POM
+type Property struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+// Plugin is a build plugin declared in a POM, with the parts of its configuration the buildpack needs to understand
+type Plugin struct {
+	GroupID          string      `xml:"groupId"`
+	ArtifactID       string      `xml:"artifactId"`
+	Classifier       string      `xml:"configuration>classifier"`
+	Executable       string      `xml:"configuration>executable"`
+	WorkingDirectory string      `xml:"configuration>workingDirectory"`
+	Executions       []Execution `xml:"executions>execution"`
+}
+
+// Execution is an execution of a build plugin.  Its configuration overrides the configuration of the plugin.
+type Execution struct {
+	ID               string   `xml:"id"`
+	Goals            []string `xml:"goals>goal"`
+	Executable       string   `xml:"configuration>executable"`
+	WorkingDirectory string   `xml:"configuration>workingDirectory"`
+}
+
+// Profile is a build profile declared in a POM
+type Profile struct {
+	ID      string   `xml:"id"`
+	Plugins []Plugin `xml:"build>plugins>plugin"`
+}
+
+// Parent is the parent of a POM.  With the 4.1.0 model, any of its coordinates can be omitted and are inferred from
+// the POM at RelativePath.  RelativePath is nil if the POM does not declare it, and empty if the parent is not to be
+// looked up on disk.
+type Parent struct {
+	GroupID      string  `xml:"groupId"`
+	ArtifactID   string  `xml:"artifactId"`
+	Version      string  `xml:"version"`
+	RelativePath *string `xml:"relativePath"`
+}
+
+// ReadPOM reads an XML POM
+func ReadPOM(file string) (POM, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return POM{}, fmt.Errorf("unable to read %s\n%w", file, err)
+	}
+
+	var p POM
+	if err := xml.Unmarshal(b, &p); err != nil {
+		return POM{}, fmt.Errorf("unable to parse %s\n%w", file, err)
+	}
+
+	return p, nil
+}
+
+// IsMaven4 determines if the POM uses the 4.1.0 model, which only Maven 4 can build
+func (p POM) IsMaven4() bool {
+	return strings.TrimSpace(p.ModelVersion) == ModelVersion4
+}
+
+// IsRoot determines if the POM in dir is the root of its multi-project build, as Maven 4 finds it: the POM declares
+// root="true" or is next to a .mvn directory
+func (p POM) IsRoot(dir string) bool {
+	if p.Root {
+		return true
+	}
+	s, err := os.Stat(filepath.Join(dir, ".mvn"))
+	return err == nil && s.IsDir()
+}
+
+// IsAggregator determines if the POM only aggregates other projects
+func (p POM) IsAggregator() bool {
+	return strings.TrimSpace(p.Packaging) == "pom"
+}
+
+// HasPlugin determines if the POM declares a plugin, in its build or the build of any of its profiles.  A plugin
+// declared without a groupId is in the default org.apache.maven.plugins group.
+func (p POM) HasPlugin(groupID string, artifactID string) bool {
+	_, ok := p.Plugin(groupID, artifactID)
+	return ok
+}
+
+// Plugin returns the first declaration of a plugin, preferring the build to the build of any of its profiles
+func (p POM) Plugin(groupID string, artifactID string) (Plugin, bool) {
+	plugins := append([]Plugin{}, p.Plugins...)
+	for _, profile := range p.Profiles {
+		plugins = append(plugins, profile.Plugins...)
+	}
+
+	for _, plugin := range plugins {
+		group := strings.TrimSpace(plugin.GroupID)
+		if group == "" {
+			group = "org.apache.maven.plugins"
+		}
+		if group == groupID && strings.TrimSpace(plugin.ArtifactID) == artifactID {
+			return plugin, true
+		}
+	}
+	return Plugin{}, false
+}
+
+// Interpolate replaces the project coordinates and properties of the POM referenced in s.  References that cannot be
+// resolved from the POM alone, such as properties inherited from a parent, are left as they are.
+func (p POM) Interpolate(s string) string {
+	version := strings.TrimSpace(p.Version)
+	if version == "" {
+		version = strings.TrimSpace(p.Parent.Version)
+	}
+
+	values := map[string]string{
+		"project.artifactId":     strings.TrimSpace(p.ArtifactID),
+		"artifactId":             strings.TrimSpace(p.ArtifactID),
+		"project.version":        version,
+		"version":                version,
+		"project.parent.version": strings.TrimSpace(p.Parent.Version),
+	}
+	for _, e := range p.Properties.Entries {
+		values[e.XMLName.Local] = strings.TrimSpace(e.Value)
+	}
+
+	// properties can reference each other, but not endlessly
+	for i := 0; i < 10 && strings.Contains(s, "${"); i++ {
+		previous := s
+		for k, v := range values {
+			if v != "" {
+				s = strings.ReplaceAll(s, fmt.Sprintf("${%s}", k), v)
+			}
+		}
+		if s == previous {
+			break
+		}
+	}
+
+	return s
+}
+
+// HasProfile determines if the POM declares a profile
+func (p POM) HasProfile(id string) bool {
+	for _, profile := range p.Profiles {
+		if strings.TrimSpace(profile.ID) == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ProjectPaths returns the paths, relative to dir, of the projects the POM in dir aggregates.  Both modules and
+// subprojects are declared as directories, or files, relative to the POM.  With the 4.1.0 model, an aggregator that
+// declares neither includes every subdirectory that has a pom.xml.
+func (p POM) ProjectPaths(dir string) []string {
+	var paths []string
+	for _, path := range append(append([]string{}, p.Modules...), p.Subprojects...) {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, filepath.Clean(path))
+		}
+	}
+
+	if len(paths) > 0 || !p.IsMaven4() || !p.IsAggregator() {
+		return paths
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, e.Name(), "pom.xml")); err == nil {
+			paths = append(paths, e.Name())
+		}
+	}
+	sort.Strings(paths)
+
+	return paths
+}
+
+// CountProjects returns the number of projects in the reactor of the POM file, including itself.  A POM that cannot
+// be read counts as a single project.
+func CountProjects(file string) int {
+	return countProjects(file, map[string]bool{})
+}
+
+func countProjects(file string, visited map[string]bool) int {
+	if visited[file] {
+		return 0
+	}
+	visited[file] = true
+
+	pom, err := ReadPOM(file)
+	if err != nil {
+		return 1
+	}
+
+	count := 1
+	for _, p := range pom.ProjectPaths(filepath.Dir(file)) {
+		count += countProjects(pomFile(filepath.Join(filepath.Dir(file), p)), visited)
+	}
+	return count
+}
+
+// ReactorProject is a project in a reactor and the POM file it was read from
+type ReactorProject struct {
+	File string
+	POM  POM
+}
+
+// ReadReactor reads the projects in the reactor of the POM file, starting with itself.  Projects whose POM cannot be
+// read are left out.
+func ReadReactor(file string) []ReactorProject {
+	return readReactor(file, map[string]bool{})
+}
+
+func readReactor(file string, visited map[string]bool) []ReactorProject {
+	if visited[file] {
+		return nil
+	}
+	visited[file] = true
+
+	pom, err := ReadPOM(file)
+	if err != nil {
+		return nil
+	}
+
+	projects := []ReactorProject{{File: file, POM: pom}}
+	for _, p := range pom.ProjectPaths(filepath.Dir(file)) {
+		projects = append(projects, readReactor(pomFile(filepath.Join(filepath.Dir(file), p)), visited)...)
+	}
+	return projects
+}
+
+// NestedPOMSearchDepth is how many directories deep FindRootPOMs looks for POMs
+const NestedPOMSearchDepth = 3
+
+// FindRootPOMs returns the POM files, relative to root, that are not a module of another POM in the tree, looking at
+// most depth directories deep.  A POM is a module of another if the other aggregates it, or if it is the parent at the
+// relativePath of the POM, which defaults to ../pom.xml and is not looked up when empty, unless the POM is the root of
+// its build.  If any of the POMs are the root of their build, only those are returned.
+func FindRootPOMs(root string, depth int) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path == root {
+				return nil
+			}
+			if strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules" || d.Name() == "target" {
+				return filepath.SkipDir
+			}
+			if rel, err := filepath.Rel(root, path); err == nil && len(strings.Split(rel, string(filepath.Separator))) > depth {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.Name() == "pom.xml" {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to search %s for POMs\n%w", root, err)
+	}
+
+	found := map[string]bool{}
+	for _, f := range files {
+		found[f] = true
+	}
+
+	modules, marked := map[string]bool{}, map[string]bool{}
+	for _, f := range files {
+		pom, err := ReadPOM(f)
+		if err != nil {
+			continue
+		}
+		marked[f] = pom.IsRoot(filepath.Dir(f))
+
+		for _, p := range pom.ProjectPaths(filepath.Dir(f)) {
+			module := pomFile(filepath.Join(filepath.Dir(f), p))
+			// an aggregator of an ancestor does not make the ancestor a module
+			if rel, err := filepath.Rel(filepath.Dir(module), filepath.Dir(f)); err == nil && !strings.HasPrefix(rel, "..") {
+				continue
+			}
+			modules[module] = true
+		}
+
+		if pom.Parent != (Parent{}) && !marked[f] {
+			relativePath := filepath.Join("..", "pom.xml")
+			if pom.Parent.RelativePath != nil {
+				relativePath = strings.TrimSpace(*pom.Parent.RelativePath)
+			}
+			if relativePath != "" && found[pomFile(filepath.Join(filepath.Dir(f), relativePath))] {
+				modules[f] = true
+			}
+		}
+	}
+
+	var roots, markedRoots []string
+	for _, f := range files {
+		if modules[f] {
+			continue
+		}
+		if rel, err := filepath.Rel(root, f); err == nil {
+			roots = append(roots, rel)
+			if marked[f] {
+				markedRoots = append(markedRoots, rel)
+			}
+		}
+	}
+	if len(markedRoots) > 0 {
+		roots = markedRoots
+	}
+	sort.Strings(roots)
+
+	return roots, nil
+}
+
+// pomFile returns the path of the POM file of a project path, which may be the file itself
+func pomFile(path string) string {
+	if s, err := os.Stat(path); err == nil && s.IsDir() {
+		return filepath.Join(path, "pom.xml")
+	}
+	return path
+}
@@ -0,0 +1,236 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/maven/v6/maven"
+)
+
+func testPOM(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		path string
+	)
+
+	it.Before(func() {
+		var err error
+
+		path, err = os.MkdirTemp("", "pom")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(path)).To(Succeed())
+	})
+
+	it("reads a 4.1.0 model POM", func() {
+		Expect(os.WriteFile(filepath.Join(path, "pom.xml"), []byte(`<project xmlns="http://maven.apache.org/POM/4.1.0" root="true">
+  <modelVersion>4.1.0</modelVersion>
+  <parent>
+    <groupId>com.example</groupId>
+  </parent>
+  <artifactId>test-artifact</artifactId>
+  <packaging>pom</packaging>
+  <subprojects>
+    <subproject>core</subproject>
+    <subproject>app/pom.xml</subproject>
+  </subprojects>
+</project>`), 0644)).To(Succeed())
+
+		pom, err := maven.ReadPOM(filepath.Join(path, "pom.xml"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(pom.IsMaven4()).To(BeTrue())
+		Expect(pom.Root).To(BeTrue())
+		Expect(pom.IsAggregator()).To(BeTrue())
+		Expect(pom.Parent.GroupID).To(Equal("com.example"))
+		Expect(pom.Parent.Version).To(BeEmpty())
+		Expect(pom.ProjectPaths(path)).To(Equal([]string{"core", "app/pom.xml"}))
+	})
+
+	it("reads a 4.0.0 model POM", func() {
+		Expect(os.WriteFile(filepath.Join(path, "pom.xml"), []byte(`<project>
+  <modelVersion>4.0.0</modelVersion>
+  <modules>
+    <module>core</module>
+  </modules>
+</project>`), 0644)).To(Succeed())
+
+		pom, err := maven.ReadPOM(filepath.Join(path, "pom.xml"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(pom.IsMaven4()).To(BeFalse())
+		Expect(pom.IsAggregator()).To(BeFalse())
+		Expect(pom.ProjectPaths(path)).To(Equal([]string{"core"}))
+	})
+
+	it("reads the plugins and profiles of a POM", func() {
+		Expect(os.WriteFile(filepath.Join(path, "pom.xml"), []byte(`<project>
+  <build>
+    <plugins>
+      <plugin>
+        <artifactId>maven-jar-plugin</artifactId>
+      </plugin>
+    </plugins>
+  </build>
+  <profiles>
+    <profile>
+      <id>native</id>
+      <build>
+        <plugins>
+          <plugin>
+            <groupId>org.graalvm.buildtools</groupId>
+            <artifactId>native-maven-plugin</artifactId>
+          </plugin>
+        </plugins>
+      </build>
+    </profile>
+  </profiles>
+</project>`), 0644)).To(Succeed())
+
+		pom, err := maven.ReadPOM(filepath.Join(path, "pom.xml"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(pom.HasPlugin("org.apache.maven.plugins", "maven-jar-plugin")).To(BeTrue())
+		Expect(pom.HasPlugin("org.graalvm.buildtools", "native-maven-plugin")).To(BeTrue())
+		Expect(pom.HasPlugin("org.apache.maven.plugins", "maven-war-plugin")).To(BeFalse())
+		Expect(pom.HasProfile("native")).To(BeTrue())
+		Expect(pom.HasProfile("prod")).To(BeFalse())
+	})
+
+	it("discovers the subprojects of a 4.1.0 aggregator that does not declare them", func() {
+		Expect(os.MkdirAll(filepath.Join(path, "b"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, "b", "pom.xml"), []byte{}, 0644)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(path, "a"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, "a", "pom.xml"), []byte{}, 0644)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(path, "src"), 0755)).To(Succeed())
+
+		pom := maven.POM{ModelVersion: "4.1.0", Packaging: "pom"}
+		Expect(pom.ProjectPaths(path)).To(Equal([]string{"a", "b"}))
+
+		pom.ModelVersion = "4.0.0"
+		Expect(pom.ProjectPaths(path)).To(BeEmpty())
+	})
+
+	it("counts the projects of a reactor", func() {
+		Expect(os.WriteFile(filepath.Join(path, "pom.xml"), []byte(`<project>
+  <packaging>pom</packaging>
+  <modules>
+    <module>core</module>
+    <module>app</module>
+  </modules>
+</project>`), 0644)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(path, "core", "api"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, "core", "pom.xml"), []byte(`<project>
+  <packaging>pom</packaging>
+  <modules>
+    <module>api</module>
+    <module>..</module>
+  </modules>
+</project>`), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, "core", "api", "pom.xml"), []byte(`<project/>`), 0644)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(path, "app"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, "app", "pom.xml"), []byte(`<project/>`), 0644)).To(Succeed())
+
+		Expect(maven.CountProjects(filepath.Join(path, "pom.xml"))).To(Equal(4))
+	})
+
+	it("finds the POMs that are not modules of another", func() {
+		for _, dir := range []string{"backend/app", "backend/lib", "tools", "frontend/node_modules/x", ".git", "deep/a/b/c"} {
+			Expect(os.MkdirAll(filepath.Join(path, dir), 0755)).To(Succeed())
+		}
+		Expect(os.WriteFile(filepath.Join(path, "backend", "pom.xml"), []byte(`<project>
+  <packaging>pom</packaging>
+  <modules>
+    <module>app</module>
+  </modules>
+</project>`), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, "backend", "app", "pom.xml"), []byte(`<project/>`), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, "backend", "lib", "pom.xml"), []byte(`<project>
+  <parent>
+    <artifactId>backend</artifactId>
+  </parent>
+</project>`), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, "tools", "pom.xml"), []byte(`<project>
+  <parent>
+    <artifactId>spring-boot-starter-parent</artifactId>
+    <relativePath/>
+  </parent>
+</project>`), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, "frontend", "node_modules", "x", "pom.xml"), []byte(`<project/>`), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, ".git", "pom.xml"), []byte(`<project/>`), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, "deep", "a", "b", "c", "pom.xml"), []byte(`<project/>`), 0644)).To(Succeed())
+
+		Expect(maven.FindRootPOMs(path, maven.NestedPOMSearchDepth)).To(Equal([]string{
+			filepath.Join("backend", "pom.xml"),
+			filepath.Join("tools", "pom.xml"),
+		}))
+	})
+
+	it("prefers the POMs that are the root of their build", func() {
+		for _, dir := range []string{"platform", "service", "tools/.mvn"} {
+			Expect(os.MkdirAll(filepath.Join(path, dir), 0755)).To(Succeed())
+		}
+		Expect(os.WriteFile(filepath.Join(path, "platform", "pom.xml"), []byte(`<project>
+  <packaging>pom</packaging>
+</project>`), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, "service", "pom.xml"), []byte(`<project xmlns="http://maven.apache.org/POM/4.1.0" root="true">
+  <modelVersion>4.1.0</modelVersion>
+  <parent>
+    <relativePath>../platform/pom.xml</relativePath>
+  </parent>
+</project>`), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, "tools", "pom.xml"), []byte(`<project/>`), 0644)).To(Succeed())
+
+		Expect(maven.FindRootPOMs(path, maven.NestedPOMSearchDepth)).To(Equal([]string{
+			filepath.Join("service", "pom.xml"),
+			filepath.Join("tools", "pom.xml"),
+		}))
+	})
+
+	it("does not look up a parent with an empty relativePath", func() {
+		Expect(os.MkdirAll(filepath.Join(path, "platform", "service"), 0755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(path, "platform", "worker"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, "platform", "pom.xml"), []byte(`<project>
+  <packaging>pom</packaging>
+</project>`), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, "platform", "service", "pom.xml"), []byte(`<project>
+  <parent>
+    <artifactId>spring-boot-starter-parent</artifactId>
+    <relativePath/>
+  </parent>
+</project>`), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, "platform", "worker", "pom.xml"), []byte(`<project>
+  <parent>
+    <artifactId>platform</artifactId>
+  </parent>
+</project>`), 0644)).To(Succeed())
+
+		Expect(maven.FindRootPOMs(path, maven.NestedPOMSearchDepth)).To(Equal([]string{
+			filepath.Join("platform", "pom.xml"),
+			filepath.Join("platform", "service", "pom.xml"),
+		}))
+	})
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/buildpacks/libcnb"
+)
+
+const (
+	// CloudNativeProfile is activated whenever a project declares it, to configure the build for a container
+	CloudNativeProfile = "cloud-native"
+
+	// PlanMetadataProfiles is the metadata of the maven or jvm-application-package requirements with the profiles
+	// other buildpacks need activated, either as a list or a comma separated string
+	PlanMetadataProfiles = "profiles"
+)
+
+// PlanProfiles returns the profiles requested by every requirement of the plan Maven builds, in order and without
+// duplicates.  The requirements are not merged, as a merge keeps the profiles of a single one.
+func PlanProfiles(plan libcnb.BuildpackPlan) ([]string, error) {
+	var profiles []string
+	for _, e := range plan.Entries {
+		if e.Name != PlanEntryMaven && e.Name != PlanEntryJVMApplicationPackage {
+			continue
+		}
+
+		var values []string
+		switch v := e.Metadata[PlanMetadataProfiles].(type) {
+		case nil:
+		case string:
+			values = strings.Split(v, ",")
+		case []string:
+			values = v
+		case []interface{}:
+			for _, p := range v {
+				s, ok := p.(string)
+				if !ok {
+					return nil, fmt.Errorf("unable to read profile %v requested by the %s plan entry, it is not a string", p, e.Name)
+				}
+				values = append(values, s)
+			}
+		default:
+			return nil, fmt.Errorf("unable to read profiles %v requested by the %s plan entry, they are not a list or a string", v, e.Name)
+		}
+
+		for _, p := range values {
+			if p = strings.TrimSpace(p); p != "" && !contains(profiles, []string{p}) {
+				profiles = append(profiles, p)
+			}
+		}
+	}
+	return profiles, nil
+}
+
+// profileID returns the id of a profile as it is passed to -P, without the prefix that deactivates it or makes it
+// optional
+func profileID(profile string) string {
+	return strings.TrimLeft(profile, "!-?")
+}
+
+// mentionsProfile determines if a profile is activated, deactivated or made optional by any of profiles
+func mentionsProfile(profiles []string, id string) bool {
+	for _, p := range profiles {
+		if profileID(p) == id {
+			return true
+		}
+	}
+	return false
+}
+
+// UndeclaredProfiles returns the profiles, as passed to -P, that no project of the reactor declares.  Optional
+// profiles, prefixed with ?, are left out as Maven ignores them when they do not exist.  Profiles that are declared in
+// a parent outside of the reactor or in the Maven settings cannot be found.
+func UndeclaredProfiles(projects []ReactorProject, profiles []string) []string {
+	var undeclared []string
+	for _, p := range profiles {
+		if strings.HasPrefix(p, "?") {
+			continue
+		}
+
+		declared := false
+		for _, project := range projects {
+			if project.POM.HasProfile(profileID(p)) {
+				declared = true
+				break
+			}
+		}
+		if !declared {
+			undeclared = append(undeclared, p)
+		}
+	}
+	return undeclared
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven_test
+
+import (
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/maven/v6/maven"
+)
+
+func testProfiles(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+	)
+
+	it("collects the profiles requested by every requirement", func() {
+		Expect(maven.PlanProfiles(libcnb.BuildpackPlan{Entries: []libcnb.BuildpackPlanEntry{
+			{Name: "maven", Metadata: map[string]interface{}{"profiles": []interface{}{"a", "b"}}},
+			{Name: "jdk", Metadata: map[string]interface{}{"profiles": "c"}},
+			{Name: "jvm-application-package", Metadata: map[string]interface{}{"profiles": " b, d "}},
+			{Name: "maven"},
+		}})).To(Equal([]string{"a", "b", "d"}))
+	})
+
+	it("fails with a profile that is not a string", func() {
+		_, err := maven.PlanProfiles(libcnb.BuildpackPlan{Entries: []libcnb.BuildpackPlanEntry{
+			{Name: "maven", Metadata: map[string]interface{}{"profiles": []interface{}{"a", 1}}},
+		}})
+		Expect(err).To(MatchError("unable to read profile 1 requested by the maven plan entry, it is not a string"))
+	})
+
+	it("finds the profiles no project declares", func() {
+		projects := []maven.ReactorProject{
+			{POM: maven.POM{Profiles: []maven.Profile{{ID: "a"}}}},
+			{POM: maven.POM{Profiles: []maven.Profile{{ID: "b"}}}},
+		}
+
+		Expect(maven.UndeclaredProfiles(projects, []string{"a", "!b", "-c", "?d", "e"})).To(Equal([]string{"-c", "e"}))
+	})
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/effect"
+)
+
+// ProjectPath returns the directory, under the application root, that $BP_MAVEN_PROJECT_PATH configures.  Maven is run
+// in it, and the POM, the Maven Wrapper, .mvn and the built artifacts are relative to it.
+func ProjectPath(applicationPath string, cr libpak.ConfigurationResolver) (string, bool, error) {
+	path, ok := cr.Resolve("BP_MAVEN_PROJECT_PATH")
+	if !ok || strings.TrimSpace(path) == "" {
+		return applicationPath, false, nil
+	}
+
+	dir := filepath.Join(applicationPath, path)
+	if rel, err := filepath.Rel(applicationPath, dir); err != nil || strings.HasPrefix(rel, "..") {
+		return "", false, fmt.Errorf("unable to use project path %s outside of the application", path)
+	}
+
+	if s, err := os.Stat(dir); err != nil {
+		return "", false, fmt.Errorf("unable to find project path %s\n%w", dir, err)
+	} else if !s.IsDir() {
+		return "", false, fmt.Errorf("unable to use project path %s, it is not a directory", dir)
+	}
+
+	return dir, true, nil
+}
+
+// WorkingDirectoryExecutor is an implementation of effect.Executor that runs the delegate in Directory
+type WorkingDirectoryExecutor struct {
+	Delegate  effect.Executor
+	Directory string
+}
+
+func (w WorkingDirectoryExecutor) Execute(execution effect.Execution) error {
+	execution.Dir = w.Directory
+	return w.Delegate.Execute(execution)
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/maven/v6/maven"
+)
+
+func testProject(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		path string
+	)
+
+	it.Before(func() {
+		var err error
+		path, err = os.MkdirTemp("", "project")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(path)).To(Succeed())
+	})
+
+	it("defaults to the application root", func() {
+		dir, ok, err := maven.ProjectPath(path, libpak.ConfigurationResolver{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeFalse())
+		Expect(dir).To(Equal(path))
+	})
+
+	it("resolves the configured project", func() {
+		t.Setenv("BP_MAVEN_PROJECT_PATH", "backend/")
+		Expect(os.MkdirAll(filepath.Join(path, "backend"), 0755)).To(Succeed())
+
+		dir, ok, err := maven.ProjectPath(path, libpak.ConfigurationResolver{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeTrue())
+		Expect(dir).To(Equal(filepath.Join(path, "backend")))
+	})
+
+	it("fails when the project is a file", func() {
+		t.Setenv("BP_MAVEN_PROJECT_PATH", "pom.xml")
+		Expect(os.WriteFile(filepath.Join(path, "pom.xml"), []byte{}, 0644)).To(Succeed())
+
+		_, _, err := maven.ProjectPath(path, libpak.ConfigurationResolver{})
+		Expect(err).To(MatchError(ContainSubstring("it is not a directory")))
+	})
+
+	it("runs in the directory", func() {
+		executor := &RecordingExecutor{}
+
+		Expect(maven.WorkingDirectoryExecutor{
+			Delegate:  executor,
+			Directory: filepath.Join(path, "backend"),
+		}.Execute(effect.Execution{Dir: path, Args: []string{"package"}})).To(Succeed())
+
+		Expect(executor.Executions).To(Equal([]effect.Execution{{Dir: filepath.Join(path, "backend"), Args: []string{"package"}}}))
+	})
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/magiconair/properties"
+)
+
+const (
+	// QuarkusPluginArtifactID is the artifactId of the Quarkus Maven plugin
+	QuarkusPluginArtifactID = "quarkus-maven-plugin"
+
+	QuarkusFastJar    = "fast-jar"
+	QuarkusMutableJar = "mutable-jar"
+	QuarkusUberJar    = "uber-jar"
+	QuarkusLegacyJar  = "legacy-jar"
+)
+
+// QuarkusGroupIDs are the groups the Quarkus Maven plugin is published in
+var QuarkusGroupIDs = []string{"io.quarkus.platform", "io.quarkus"}
+
+// QuarkusPackageTypeKeys are the configuration keys of the Quarkus packaging type, the first the current one, the
+// second the one used before Quarkus 3.9
+var QuarkusPackageTypeKeys = []string{"quarkus.package.jar.type", "quarkus.package.type"}
+
+// QuarkusArtifactPatterns are the built artifacts of each Quarkus packaging type
+var QuarkusArtifactPatterns = map[string]string{
+	QuarkusFastJar:    "target/quarkus-app/lib/ target/quarkus-app/*.jar target/quarkus-app/app/ target/quarkus-app/quarkus/",
+	QuarkusMutableJar: "target/quarkus-app/lib/ target/quarkus-app/*.jar target/quarkus-app/app/ target/quarkus-app/quarkus/",
+	QuarkusUberJar:    "target/*-runner.jar",
+	QuarkusLegacyJar:  "target/*-runner.jar target/lib/",
+}
+
+// IsQuarkus determines if the POM builds a Quarkus application
+func (p POM) IsQuarkus() bool {
+	for _, g := range QuarkusGroupIDs {
+		if p.HasPlugin(g, QuarkusPluginArtifactID) {
+			return true
+		}
+	}
+	return false
+}
+
+// QuarkusPackageType returns the Quarkus packaging type of the project in dir.  Like Quarkus, it prefers system
+// properties set in the arguments to the properties of the POM and those to src/main/resources/application.properties,
+// where the prod profile applies.  It defaults to fast-jar.
+func QuarkusPackageType(pom POM, dir string, args []string) (string, error) {
+	for _, key := range QuarkusPackageTypeKeys {
+		prefix := fmt.Sprintf("-D%s=", key)
+		for _, a := range args {
+			if strings.HasPrefix(a, prefix) {
+				return strings.TrimPrefix(a, prefix), nil
+			}
+		}
+	}
+
+	for _, key := range QuarkusPackageTypeKeys {
+		for _, e := range pom.Properties.Entries {
+			if e.XMLName.Local == key {
+				return pom.Interpolate(strings.TrimSpace(e.Value)), nil
+			}
+		}
+	}
+
+	file := filepath.Join(dir, "src", "main", "resources", "application.properties")
+	p, err := (&properties.Loader{Encoding: properties.UTF8, DisableExpansion: true, IgnoreMissing: true}).LoadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("unable to read %s\n%w", file, err)
+	}
+	for _, key := range QuarkusPackageTypeKeys {
+		if v, ok := p.Get(fmt.Sprintf("%%prod.%s", key)); ok {
+			return strings.TrimSpace(v), nil
+		}
+		if v, ok := p.Get(key); ok {
+			return strings.TrimSpace(v), nil
+		}
+	}
+
+	return QuarkusFastJar, nil
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven_test
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/maven/v6/maven"
+)
+
+func testQuarkus(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		dir string
+		pom = maven.POM{Plugins: []maven.Plugin{{GroupID: "io.quarkus", ArtifactID: "quarkus-maven-plugin"}}}
+	)
+
+	it.Before(func() {
+		var err error
+
+		dir, err = os.MkdirTemp("", "quarkus")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	it("recognizes a Quarkus application", func() {
+		Expect(maven.POM{}.IsQuarkus()).To(BeFalse())
+		Expect(pom.IsQuarkus()).To(BeTrue())
+	})
+
+	it("defaults to fast-jar", func() {
+		Expect(maven.QuarkusPackageType(pom, dir, nil)).To(Equal("fast-jar"))
+	})
+
+	context("application.properties configures the packaging type", func() {
+		it.Before(func() {
+			Expect(os.MkdirAll(filepath.Join(dir, "src", "main", "resources"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "src", "main", "resources", "application.properties"), []byte(`
+quarkus.package.jar.type=legacy-jar
+%prod.quarkus.package.jar.type=uber-jar
+%dev.quarkus.package.jar.type=mutable-jar
+`), 0644)).To(Succeed())
+		})
+
+		it("uses the prod profile", func() {
+			Expect(maven.QuarkusPackageType(pom, dir, nil)).To(Equal("uber-jar"))
+		})
+
+		it("prefers the properties of the POM", func() {
+			pom.Properties = maven.Properties{Entries: []maven.Property{{XMLName: xml.Name{Local: "quarkus.package.type"}, Value: "fast-jar"}}}
+			Expect(maven.QuarkusPackageType(pom, dir, nil)).To(Equal("fast-jar"))
+		})
+
+		it("prefers the system properties of the arguments", func() {
+			Expect(maven.QuarkusPackageType(pom, dir, []string{"-Dquarkus.package.jar.type=mutable-jar", "package"})).To(Equal("mutable-jar"))
+		})
+	})
+}
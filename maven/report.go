@@ -0,0 +1,221 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/paketo-buildpacks/libbs"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/effect"
+)
+
+const (
+	TestsSkipped = "skipped"
+	TestsPassed  = "passed"
+	TestsFailed  = "failed"
+	TestsNone    = "none"
+)
+
+// The outcomes of a build.  A build is reused when the application layer is restored from the cache without running
+// Maven, so no tests or artifacts are recorded.
+const (
+	BuildSucceeded = "succeeded"
+	BuildFailed    = "failed"
+	BuildReused    = "reused"
+)
+
+// SettingsDigests are the metadata keys of the digests of the Maven settings a build uses
+var SettingsDigests = []string{"settings-sha256", "settings-security-sha256"}
+
+// BuildSummary describes how an application was built
+type BuildSummary struct {
+	Outcome        string            `json:"outcome" toml:"outcome"`
+	Manager        string            `json:"manager" toml:"manager"`
+	Version        string            `json:"version,omitempty" toml:"version,omitempty"`
+	Command        string            `json:"command" toml:"command"`
+	Arguments      []string          `json:"arguments" toml:"arguments"`
+	ActiveProfiles []string          `json:"active-profiles" toml:"active-profiles"`
+	Settings       map[string]string `json:"settings" toml:"settings"`
+	Artifacts      []string          `json:"artifacts" toml:"artifacts"`
+	Tests          TestOutcome       `json:"tests" toml:"tests"`
+	Timings        *BuildTimer       `json:"timings,omitempty" toml:"timings,omitempty"`
+}
+
+// TestOutcome is the aggregate of the Surefire/Failsafe results printed by Maven
+type TestOutcome struct {
+	Status   string `json:"status" toml:"status"`
+	Run      int    `json:"run" toml:"run"`
+	Failures int    `json:"failures" toml:"failures"`
+	Errors   int    `json:"errors" toml:"errors"`
+	Skipped  int    `json:"skipped" toml:"skipped"`
+}
+
+func NewBuildSummary(manager MavenManager, version string, command string, args []string, md map[string]interface{}) *BuildSummary {
+	s := &BuildSummary{
+		Outcome:        BuildReused,
+		Manager:        managerName(manager),
+		Version:        version,
+		Command:        command,
+		Arguments:      args,
+		ActiveProfiles: activeProfiles(args),
+		Settings:       map[string]string{},
+		Artifacts:      []string{},
+		Tests:          TestOutcome{Status: TestsNone},
+	}
+
+	for _, k := range SettingsDigests {
+		if v, ok := md[k]; ok {
+			s.Settings[k] = fmt.Sprint(v)
+		}
+	}
+
+	if contains(args, []string{"-Dmaven.test.skip", "-Dmaven.test.skip=true", "-DskipTests", "-DskipTests=true"}) {
+		s.Tests.Status = TestsSkipped
+	}
+
+	return s
+}
+
+var testResultsLine = regexp.MustCompile(`^\[(?:INFO|WARNING|ERROR)\] Tests run: (\d+), Failures: (\d+), Errors: (\d+), Skipped: (\d+)$`)
+
+// ObserveLine adds the test results from the summary Surefire/Failsafe print at the end of each module
+func (t *TestOutcome) ObserveLine(line string) {
+	m := testResultsLine.FindStringSubmatch(line)
+	if m == nil {
+		return
+	}
+
+	counts := make([]int, 4)
+	for i := range counts {
+		counts[i], _ = strconv.Atoi(m[i+1])
+	}
+	t.Run += counts[0]
+	t.Failures += counts[1]
+	t.Errors += counts[2]
+	t.Skipped += counts[3]
+
+	if t.Status == TestsSkipped {
+		return
+	}
+	if t.Failures+t.Errors > 0 {
+		t.Status = TestsFailed
+	} else if t.Run > 0 {
+		t.Status = TestsPassed
+	}
+}
+
+// ReportingExecutor records the test outcome and resolved artifacts of a Maven execution
+type ReportingExecutor struct {
+	ArtifactResolver libbs.ArtifactResolver
+	Delegate         effect.Executor
+	Summary          *BuildSummary
+}
+
+func (r ReportingExecutor) Execute(execution effect.Execution) error {
+	w := NewLineWriter(execution.Stdout, r.Summary.Tests.ObserveLine)
+	execution.Stdout = w
+
+	err := r.Delegate.Execute(execution)
+	w.Flush()
+	if err != nil {
+		r.Summary.Outcome = BuildFailed
+		return err
+	}
+
+	// errors are reported by the application layer when it resolves the artifacts itself
+	artifacts, _ := r.ArtifactResolver.ResolveMany(execution.Dir)
+	for _, a := range artifacts {
+		if rel, err := filepath.Rel(execution.Dir, a); err == nil {
+			a = rel
+		}
+		r.Summary.Artifacts = append(r.Summary.Artifacts, a)
+	}
+	r.Summary.Outcome = BuildSucceeded
+
+	return nil
+}
+
+// BuildReport writes a BuildSummary as JSON, or TOML if the path ends in .toml.  It must be written after the
+// application layer is contributed so that the outcome of the build is known.
+type BuildReport struct {
+	Logger  bard.Logger
+	Path    string
+	Summary *BuildSummary
+}
+
+func (b BuildReport) Write() error {
+	b.Logger.Header("Writing build report")
+
+	var buf bytes.Buffer
+	if strings.HasSuffix(b.Path, ".toml") {
+		if err := toml.NewEncoder(&buf).Encode(b.Summary); err != nil {
+			return fmt.Errorf("unable to encode build report\n%w", err)
+		}
+	} else {
+		e := json.NewEncoder(&buf)
+		e.SetIndent("", "  ")
+		if err := e.Encode(b.Summary); err != nil {
+			return fmt.Errorf("unable to encode build report\n%w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(b.Path), 0755); err != nil {
+		return fmt.Errorf("unable to create directory for %s\n%w", b.Path, err)
+	}
+
+	if err := os.WriteFile(b.Path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("unable to write build report to %s\n%w", b.Path, err)
+	}
+	b.Logger.Bodyf("Wrote %s", b.Path)
+
+	return nil
+}
+
+// activeProfiles returns the profiles passed with -P/--activate-profiles
+func activeProfiles(args []string) []string {
+	profiles := []string{}
+	for i := 0; i < len(args); i++ {
+		var value string
+		switch {
+		case args[i] == "-P" || args[i] == "--activate-profiles":
+			if i+1 < len(args) {
+				i++
+				value = args[i]
+			}
+		case strings.HasPrefix(args[i], "--activate-profiles="):
+			value = strings.TrimPrefix(args[i], "--activate-profiles=")
+		case strings.HasPrefix(args[i], "-P"):
+			value = strings.TrimPrefix(args[i], "-P")
+		}
+
+		for _, p := range strings.Split(value, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				profiles = append(profiles, p)
+			}
+		}
+	}
+	return profiles
+}
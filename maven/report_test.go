@@ -0,0 +1,163 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libbs"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/maven/v6/maven"
+)
+
+func testReport(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		path string
+	)
+
+	it.Before(func() {
+		var err error
+		path, err = os.MkdirTemp("", "report")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(path)).To(Succeed())
+	})
+
+	it("summarizes the build configuration", func() {
+		summary := maven.NewBuildSummary(
+			maven.NewWrapperMavenManager(path, bard.NewLogger(&bytes.Buffer{})),
+			"3.9.6",
+			"/workspace/mvnw",
+			[]string{"--settings=/bindings/settings.xml", "-Dmaven.test.skip=true", "package", "-P", "native,?prod", "-Pcloud"},
+			map[string]interface{}{"settings-sha256": "abc", "settings-security-sha256": "def", "extensions-sha256": "ghi", "other": "value"},
+		)
+
+		Expect(summary.Manager).To(Equal("WrapperMavenManager"))
+		Expect(summary.Version).To(Equal("3.9.6"))
+		Expect(summary.ActiveProfiles).To(Equal([]string{"native", "?prod", "cloud"}))
+		Expect(summary.Settings).To(Equal(map[string]string{"settings-sha256": "abc", "settings-security-sha256": "def"}))
+		Expect(summary.Tests.Status).To(Equal(maven.TestsSkipped))
+		// the application layer is reused unless Maven runs
+		Expect(summary.Outcome).To(Equal(maven.BuildReused))
+	})
+
+	context("ReportingExecutor", func() {
+		var summary *maven.BuildSummary
+
+		it.Before(func() {
+			summary = maven.NewBuildSummary(maven.NewNoopMavenManager(libpak.ConfigurationResolver{}, bard.NewLogger(&bytes.Buffer{})), "", "mvn", []string{"package"}, map[string]interface{}{})
+
+			Expect(os.MkdirAll(filepath.Join(path, "target"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(path, "target", "app.jar"), []byte{}, 0644)).To(Succeed())
+		})
+
+		it("records the test outcome and artifacts", func() {
+			executor := maven.ReportingExecutor{
+				ArtifactResolver: libbs.ArtifactResolver{
+					ArtifactConfigurationKey: "BP_MAVEN_BUILT_ARTIFACT",
+					ConfigurationResolver: libpak.ConfigurationResolver{Configurations: []libpak.BuildpackConfiguration{
+						{Name: "BP_MAVEN_BUILT_ARTIFACT", Default: "target/*.jar"},
+					}},
+					InterestingFileDetector: libbs.AlwaysInterestingFileDetector{},
+				},
+				Delegate: FakeExecutor{Output: "[INFO] Tests run: 1, Failures: 0, Errors: 0, Skipped: 0, Time elapsed: 0.1 s - in a.Test\n" +
+					"[INFO] Tests run: 3, Failures: 0, Errors: 0, Skipped: 1\n" +
+					"[ERROR] Tests run: 2, Failures: 1, Errors: 0, Skipped: 0\n"},
+				Summary: summary,
+			}
+
+			Expect(executor.Execute(effect.Execution{Dir: path, Stdout: &bytes.Buffer{}})).To(Succeed())
+
+			Expect(summary.Outcome).To(Equal(maven.BuildSucceeded))
+			Expect(summary.Tests).To(Equal(maven.TestOutcome{Status: maven.TestsFailed, Run: 5, Failures: 1, Skipped: 1}))
+			Expect(summary.Artifacts).To(Equal([]string{filepath.Join("target", "app.jar")}))
+		})
+
+		it("records the test outcome of a failed build", func() {
+			executor := maven.ReportingExecutor{
+				Delegate: FakeExecutor{Output: "[ERROR] Tests run: 2, Failures: 1, Errors: 0, Skipped: 0\n", Err: errors.New("test-error")},
+				Summary:  summary,
+			}
+
+			Expect(executor.Execute(effect.Execution{Dir: path, Stdout: &bytes.Buffer{}})).To(MatchError("test-error"))
+
+			Expect(summary.Outcome).To(Equal(maven.BuildFailed))
+			Expect(summary.Tests).To(Equal(maven.TestOutcome{Status: maven.TestsFailed, Run: 2, Failures: 1}))
+			Expect(summary.Artifacts).To(BeEmpty())
+		})
+
+		it("passes when all tests succeed", func() {
+			summary.Tests.ObserveLine("[INFO] Tests run: 3, Failures: 0, Errors: 0, Skipped: 0")
+
+			Expect(summary.Tests.Status).To(Equal(maven.TestsPassed))
+		})
+	})
+
+	context("BuildReport", func() {
+		var summary *maven.BuildSummary
+
+		it.Before(func() {
+			summary = maven.NewBuildSummary(maven.NewNoopMavenManager(libpak.ConfigurationResolver{}, bard.NewLogger(&bytes.Buffer{})), "", "mvn", []string{"package"}, map[string]interface{}{})
+		})
+
+		it("writes JSON", func() {
+			file := filepath.Join(path, "reports", "build.json")
+
+			Expect(maven.BuildReport{Logger: bard.NewLogger(&bytes.Buffer{}), Path: file, Summary: summary}.Write()).To(Succeed())
+
+			b, err := os.ReadFile(file)
+			Expect(err).NotTo(HaveOccurred())
+
+			var report map[string]interface{}
+			Expect(json.Unmarshal(b, &report)).To(Succeed())
+			Expect(report["outcome"]).To(Equal("reused"))
+			Expect(report["manager"]).To(Equal("NoopMavenManager"))
+			Expect(report["command"]).To(Equal("mvn"))
+			Expect(report["arguments"]).To(Equal([]interface{}{"package"}))
+			Expect(report).NotTo(HaveKey("timings"))
+		})
+
+		it("writes TOML", func() {
+			file := filepath.Join(path, "build.toml")
+			summary.Timings = maven.NewBuildTimer()
+
+			Expect(maven.BuildReport{Logger: bard.NewLogger(&bytes.Buffer{}), Path: file, Summary: summary}.Write()).To(Succeed())
+
+			var report map[string]interface{}
+			_, err := toml.DecodeFile(file, &report)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report["manager"]).To(Equal("NoopMavenManager"))
+			Expect(report["tests"]).To(HaveKeyWithValue("status", "none"))
+			Expect(report).To(HaveKey("timings"))
+		})
+	})
+}
@@ -0,0 +1,184 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/paketo-buildpacks/libpak/effect"
+)
+
+const (
+	// CgroupRoot is where the cgroup filesystem is mounted in the build container
+	CgroupRoot = "/sys/fs/cgroup"
+
+	// HeapPercentage is the share of the container memory limit given to the Maven JVM heap
+	HeapPercentage = 75
+
+	// unlimitedMemory is the threshold above which cgroup v1 memory limits are considered unset
+	unlimitedMemory = int64(1) << 60
+)
+
+// ContainerResources are the CPU and memory limits of the build container
+type ContainerResources struct {
+	CPUs        int
+	CPULimited  bool
+	MemoryLimit int64
+}
+
+// DetectContainerResources reads the CPU and memory limits from cgroup v2 or v1 under root.  CPUs falls back to
+// the number of CPUs of the host, with CPULimited false, and MemoryLimit is zero if memory is not limited.
+func DetectContainerResources(root string) ContainerResources {
+	r := ContainerResources{CPUs: runtime.NumCPU()}
+
+	if quota, period, ok := cgroupV2CPU(root); ok {
+		r.CPUs, r.CPULimited = int(math.Ceil(quota/period)), true
+	} else if quota, period, ok := cgroupV1CPU(root); ok {
+		r.CPUs, r.CPULimited = int(math.Ceil(quota/period)), true
+	}
+
+	if limit, ok := readInt(filepath.Join(root, "memory.max")); ok {
+		r.MemoryLimit = limit
+	} else if limit, ok := readInt(filepath.Join(root, "memory", "memory.limit_in_bytes")); ok && limit < unlimitedMemory {
+		r.MemoryLimit = limit
+	}
+
+	return r
+}
+
+func cgroupV2CPU(root string) (float64, float64, bool) {
+	b, err := os.ReadFile(filepath.Join(root, "cpu.max"))
+	if err != nil {
+		return 0, 0, false
+	}
+
+	fields := strings.Fields(string(b))
+	if len(fields) != 2 || fields[0] == "max" {
+		return 0, 0, false
+	}
+
+	quota, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	period, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil || period <= 0 {
+		return 0, 0, false
+	}
+
+	return quota, period, true
+}
+
+func cgroupV1CPU(root string) (float64, float64, bool) {
+	quota, ok := readInt(filepath.Join(root, "cpu", "cpu.cfs_quota_us"))
+	if !ok || quota <= 0 {
+		return 0, 0, false
+	}
+
+	period, ok := readInt(filepath.Join(root, "cpu", "cpu.cfs_period_us"))
+	if !ok || period <= 0 {
+		return 0, 0, false
+	}
+
+	return float64(quota), float64(period), true
+}
+
+func readInt(file string) (int64, bool) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return 0, false
+	}
+
+	v, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return v, true
+}
+
+// MavenOpts returns the JVM options for Maven sized to the memory limit, or an empty string if memory is not limited
+func (c ContainerResources) MavenOpts() string {
+	if c.MemoryLimit <= 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("-Xmx%dm", c.MemoryLimit*HeapPercentage/100/1024/1024)
+}
+
+// Threads returns the number of threads for a parallel Maven build, or an empty string if the build should be serial.
+// The build is only parallel if a CPU quota is set, as the CPUs of the host are not necessarily available to it.
+func (c ContainerResources) Threads() string {
+	if !c.CPULimited || c.CPUs <= 1 {
+		return ""
+	}
+
+	return strconv.Itoa(c.CPUs)
+}
+
+// setsHeap determines if JVM options already size the heap
+func setsHeap(options string) bool {
+	for _, o := range strings.Fields(options) {
+		if strings.HasPrefix(o, "-Xmx") || strings.HasPrefix(o, "-XX:MaxRAM") || strings.HasPrefix(o, "-XX:MaxHeapSize") {
+			return true
+		}
+	}
+	return false
+}
+
+// setsThreads determines if Maven arguments already configure a parallel build
+func setsThreads(args []string) bool {
+	for _, a := range args {
+		if a == "-T" || a == "--threads" || strings.HasPrefix(a, "--threads=") || (strings.HasPrefix(a, "-T") && len(a) > 2) {
+			return true
+		}
+	}
+	return false
+}
+
+// EnvironmentExecutor runs a command with additional environment variables, replacing any existing values
+type EnvironmentExecutor struct {
+	Delegate    effect.Executor
+	Environment map[string]string
+}
+
+func (e EnvironmentExecutor) Execute(execution effect.Execution) error {
+	env := execution.Env
+	if len(env) == 0 {
+		env = os.Environ()
+	}
+
+	var result []string
+	for _, v := range env {
+		name, _, _ := strings.Cut(v, "=")
+		if _, ok := e.Environment[name]; !ok {
+			result = append(result, v)
+		}
+	}
+	for k, v := range e.Environment {
+		result = append(result, fmt.Sprintf("%s=%s", k, v))
+	}
+	execution.Env = result
+
+	return e.Delegate.Execute(execution)
+}
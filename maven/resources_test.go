@@ -0,0 +1,110 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/maven/v6/maven"
+)
+
+func testResources(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		root string
+	)
+
+	it.Before(func() {
+		var err error
+		root, err = os.MkdirTemp("", "cgroup")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(root)).To(Succeed())
+	})
+
+	it("falls back to the host without limits", func() {
+		Expect(maven.DetectContainerResources(root)).To(Equal(maven.ContainerResources{CPUs: runtime.NumCPU()}))
+	})
+
+	it("reads cgroup v2 limits", func() {
+		Expect(os.WriteFile(filepath.Join(root, "cpu.max"), []byte("250000 100000\n"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(root, "memory.max"), []byte("2147483648\n"), 0644)).To(Succeed())
+
+		Expect(maven.DetectContainerResources(root)).To(Equal(maven.ContainerResources{CPUs: 3, CPULimited: true, MemoryLimit: 2147483648}))
+	})
+
+	it("ignores unlimited cgroup v2 limits", func() {
+		Expect(os.WriteFile(filepath.Join(root, "cpu.max"), []byte("max 100000\n"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(root, "memory.max"), []byte("max\n"), 0644)).To(Succeed())
+
+		Expect(maven.DetectContainerResources(root)).To(Equal(maven.ContainerResources{CPUs: runtime.NumCPU()}))
+	})
+
+	it("reads cgroup v1 limits", func() {
+		Expect(os.MkdirAll(filepath.Join(root, "cpu"), 0755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(root, "memory"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(root, "cpu", "cpu.cfs_quota_us"), []byte("200000\n"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(root, "cpu", "cpu.cfs_period_us"), []byte("100000\n"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(root, "memory", "memory.limit_in_bytes"), []byte("1073741824\n"), 0644)).To(Succeed())
+
+		Expect(maven.DetectContainerResources(root)).To(Equal(maven.ContainerResources{CPUs: 2, CPULimited: true, MemoryLimit: 1073741824}))
+	})
+
+	it("ignores unlimited cgroup v1 limits", func() {
+		Expect(os.MkdirAll(filepath.Join(root, "cpu"), 0755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(root, "memory"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(root, "cpu", "cpu.cfs_quota_us"), []byte("-1\n"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(root, "cpu", "cpu.cfs_period_us"), []byte("100000\n"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(root, "memory", "memory.limit_in_bytes"), []byte("9223372036854771712\n"), 0644)).To(Succeed())
+
+		Expect(maven.DetectContainerResources(root)).To(Equal(maven.ContainerResources{CPUs: runtime.NumCPU()}))
+	})
+
+	it("sizes the heap and threads", func() {
+		Expect(maven.ContainerResources{CPUs: 4, CPULimited: true, MemoryLimit: 2147483648}.MavenOpts()).To(Equal("-Xmx1536m"))
+		Expect(maven.ContainerResources{CPUs: 4, CPULimited: true, MemoryLimit: 2147483648}.Threads()).To(Equal("4"))
+		Expect(maven.ContainerResources{CPUs: 1, CPULimited: true}.MavenOpts()).To(BeEmpty())
+		Expect(maven.ContainerResources{CPUs: 1, CPULimited: true}.Threads()).To(BeEmpty())
+	})
+
+	it("builds serially without a CPU quota", func() {
+		Expect(maven.ContainerResources{CPUs: 4}.Threads()).To(BeEmpty())
+		Expect(maven.DetectContainerResources(root).Threads()).To(BeEmpty())
+	})
+
+	it("replaces environment variables", func() {
+		executor := &RecordingExecutor{}
+
+		Expect(maven.EnvironmentExecutor{
+			Delegate:    executor,
+			Environment: map[string]string{"MAVEN_OPTS": "-Xmx1g"},
+		}.Execute(effect.Execution{Env: []string{"A=B", "MAVEN_OPTS=-Xmx2g"}})).To(Succeed())
+
+		Expect(executor.Executions).To(HaveLen(1))
+		Expect(executor.Executions[0].Env).To(Equal([]string{"A=B", "MAVEN_OPTS=-Xmx1g"}))
+	})
+}
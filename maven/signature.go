@@ -0,0 +1,154 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+const (
+	publicKeyBlockBegin = "-----BEGIN PGP PUBLIC KEY BLOCK-----"
+	publicKeyBlockEnd   = "-----END PGP PUBLIC KEY BLOCK-----"
+)
+
+// SignatureVerifier verifies a downloaded distribution against its detached, armored signature, as published next to
+// the Apache distributions with an .asc extension
+type SignatureVerifier struct {
+	KeyRing      openpgp.EntityList
+	KeysSHA256   string
+	SignatureURI string
+}
+
+// NewSignatureVerifier creates a SignatureVerifier trusting the keys in an Apache KEYS file
+func NewSignatureVerifier(keysFile string, signatureURI string) (SignatureVerifier, error) {
+	b, err := os.ReadFile(keysFile)
+	if err != nil {
+		return SignatureVerifier{}, fmt.Errorf("unable to read %s\n%w", keysFile, err)
+	}
+
+	keyRing, err := ReadKeys(string(b))
+	if err != nil {
+		return SignatureVerifier{}, fmt.Errorf("unable to read keys from %s\n%w", keysFile, err)
+	}
+
+	sum := sha256.Sum256(b)
+	return SignatureVerifier{KeyRing: keyRing, KeysSHA256: hex.EncodeToString(sum[:]), SignatureURI: signatureURI}, nil
+}
+
+// ReadKeys reads every armored public key block of a KEYS file, ignoring the text between them
+func ReadKeys(keys string) (openpgp.EntityList, error) {
+	var keyRing openpgp.EntityList
+	for {
+		start := strings.Index(keys, publicKeyBlockBegin)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(keys[start:], publicKeyBlockEnd)
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated public key block")
+		}
+		end += start + len(publicKeyBlockEnd)
+
+		entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(keys[start:end]))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read public key block\n%w", err)
+		}
+		keyRing = append(keyRing, entities...)
+
+		keys = keys[end:]
+	}
+
+	if len(keyRing) == 0 {
+		return nil, fmt.Errorf("no public keys found")
+	}
+
+	return keyRing, nil
+}
+
+// Verify checks that artifact is signed by one of the trusted keys, returning the signer.  The artifact is read from
+// the start and rewound afterwards so that it can be expanded.
+func (s SignatureVerifier) Verify(artifact *os.File) (string, error) {
+	signature, err := s.signature()
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := artifact.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("unable to rewind %s\n%w", artifact.Name(), err)
+	}
+	signer, err := openpgp.CheckArmoredDetachedSignature(s.KeyRing, artifact, bytes.NewReader(signature), nil)
+	if err != nil {
+		return "", fmt.Errorf("signature %s does not match\n%w", s.SignatureURI, err)
+	}
+	if _, err := artifact.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("unable to rewind %s\n%w", artifact.Name(), err)
+	}
+
+	var names []string
+	for name := range signer.Identities {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return fmt.Sprintf("%X", signer.PrimaryKey.Fingerprint), nil
+	}
+	sort.Strings(names)
+	return names[0], nil
+}
+
+func (s SignatureVerifier) signature() ([]byte, error) {
+	u, err := url.Parse(s.SignatureURI)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse signature URI %s\n%w", s.SignatureURI, err)
+	}
+
+	if u.Scheme == "file" {
+		b, err := os.ReadFile(u.Path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read signature %s\n%w", s.SignatureURI, err)
+		}
+		return b, nil
+	}
+
+	client := http.Client{Timeout: time.Minute}
+	resp, err := client.Get(s.SignatureURI)
+	if err != nil {
+		return nil, fmt.Errorf("unable to download signature %s\n%w", s.SignatureURI, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unable to download signature %s: %d", s.SignatureURI, resp.StatusCode)
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read signature %s\n%w", s.SignatureURI, err)
+	}
+	return b, nil
+}
@@ -0,0 +1,211 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/maven/v6/maven"
+)
+
+func testSignature(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		artifact = filepath.Join("testdata", "31ba45356e22aff670af88170f43ff82328e6f323c3ce891ba422bd1031e3308",
+			"stub-maven-distribution.tar.gz")
+		dep = libpak.BuildpackDependency{
+			URI:    "https://localhost/stub-maven-distribution.tar.gz",
+			SHA256: "31ba45356e22aff670af88170f43ff82328e6f323c3ce891ba422bd1031e3308",
+		}
+
+		ctx     libcnb.BuildContext
+		dir     string
+		signer  *openpgp.Entity
+		keys    string
+		sign    func(entity *openpgp.Entity) string
+		publish func(entity *openpgp.Entity) string
+	)
+
+	publish = func(entity *openpgp.Entity) string {
+		b := &bytes.Buffer{}
+		w, err := armor.Encode(b, openpgp.PublicKeyType, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entity.Serialize(w)).To(Succeed())
+		Expect(w.Close()).To(Succeed())
+		return b.String()
+	}
+
+	sign = func(entity *openpgp.Entity) string {
+		in, err := os.Open(artifact)
+		Expect(err).NotTo(HaveOccurred())
+		defer in.Close()
+
+		file := filepath.Join(dir, "stub-maven-distribution.tar.gz.asc")
+		out, err := os.Create(file)
+		Expect(err).NotTo(HaveOccurred())
+		defer out.Close()
+
+		Expect(openpgp.ArmoredDetachSign(out, entity, in, nil)).To(Succeed())
+		return fmt.Sprintf("file://%s", file)
+	}
+
+	it.Before(func() {
+		var err error
+
+		ctx.Layers.Path, err = os.MkdirTemp("", "signature-layers")
+		Expect(err).NotTo(HaveOccurred())
+
+		dir, err = os.MkdirTemp("", "signature")
+		Expect(err).NotTo(HaveOccurred())
+
+		signer, err = openpgp.NewEntity("Test Signer", "", "signer@example.com", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		keys = filepath.Join(dir, "KEYS")
+		Expect(os.WriteFile(keys, []byte(fmt.Sprintf("This file contains the PGP keys of the developers.\n\npub   rsa2048\n%s\n", publish(signer))), 0644)).To(Succeed())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(ctx.Layers.Path)).To(Succeed())
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	it("reads every key of a KEYS file", func() {
+		other, err := openpgp.NewEntity("Other Signer", "", "other@example.com", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		keyRing, err := maven.ReadKeys(fmt.Sprintf("pub one\n%s\npub two\n%s", publish(signer), publish(other)))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keyRing).To(HaveLen(2))
+
+		_, err = maven.ReadKeys("no keys here")
+		Expect(err).To(MatchError("no public keys found"))
+	})
+
+	it("contributes a distribution with a matching signature", func() {
+		verifier, err := maven.NewSignatureVerifier(keys, sign(signer))
+		Expect(err).NotTo(HaveOccurred())
+
+		d, _ := maven.NewDistribution(dep, libpak.DependencyCache{CachePath: "testdata"})
+		d.Verifier = &verifier
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err = d.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(filepath.Join(layer.Path, "fixture-marker")).To(BeARegularFile())
+	})
+
+	it("downloads the signature", func() {
+		b, err := os.ReadFile(sign(signer)[len("file://"):])
+		Expect(err).NotTo(HaveOccurred())
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/stub-maven-distribution.tar.gz.asc" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(b)
+		}))
+		defer server.Close()
+
+		verifier, err := maven.NewSignatureVerifier(keys, server.URL+"/stub-maven-distribution.tar.gz.asc")
+		Expect(err).NotTo(HaveOccurred())
+
+		in, err := os.Open(artifact)
+		Expect(err).NotTo(HaveOccurred())
+		defer in.Close()
+
+		Expect(verifier.Verify(in)).To(Equal("Test Signer <signer@example.com>"))
+	})
+
+	it("fails the contribution when the signature does not match", func() {
+		other, err := openpgp.NewEntity("Other Signer", "", "other@example.com", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		verifier, err := maven.NewSignatureVerifier(keys, sign(other))
+		Expect(err).NotTo(HaveOccurred())
+
+		d, _ := maven.NewDistribution(dep, libpak.DependencyCache{CachePath: "testdata"})
+		d.Verifier = &verifier
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = d.Contribute(layer)
+		Expect(err).To(MatchError(ContainSubstring("unable to verify Maven")))
+		Expect(filepath.Join(layer.Path, "fixture-marker")).NotTo(BeAnExistingFile())
+	})
+
+	it("verifies a distribution contributed before the keys were bound", func() {
+		other, err := openpgp.NewEntity("Other Signer", "", "other@example.com", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		d, _ := maven.NewDistribution(dep, libpak.DependencyCache{CachePath: "testdata"})
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err = d.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		verifier, err := maven.NewSignatureVerifier(keys, sign(other))
+		Expect(err).NotTo(HaveOccurred())
+		d.Verifier = &verifier
+
+		_, err = d.Contribute(layer)
+		Expect(err).To(MatchError(ContainSubstring("unable to verify Maven")))
+	})
+
+	it("verifies the distribution again when the keys change", func() {
+		verifier, err := maven.NewSignatureVerifier(keys, sign(signer))
+		Expect(err).NotTo(HaveOccurred())
+
+		d, _ := maven.NewDistribution(dep, libpak.DependencyCache{CachePath: "testdata"})
+		d.Verifier = &verifier
+		layer, err := ctx.Layers.Layer("test-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err = d.Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(layer.Metadata).To(HaveKeyWithValue("keys-sha256", verifier.KeysSHA256))
+
+		other, err := openpgp.NewEntity("Other Signer", "", "other@example.com", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(keys, []byte(publish(other)), 0644)).To(Succeed())
+
+		verifier, err = maven.NewSignatureVerifier(keys, verifier.SignatureURI)
+		Expect(err).NotTo(HaveOccurred())
+		d.Verifier = &verifier
+
+		_, err = d.Contribute(layer)
+		Expect(err).To(MatchError(ContainSubstring("unable to verify Maven")))
+	})
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/effect"
+)
+
+const (
+	// SpringBootGroupID is the group of the Spring Boot parent POM and Maven plugin
+	SpringBootGroupID          = "org.springframework.boot"
+	SpringBootParentArtifactID = "spring-boot-starter-parent"
+	SpringBootPluginArtifactID = "spring-boot-maven-plugin"
+
+	// SpringBootBuildInfoGoal generates META-INF/build-info.properties, so that it is packaged with the application
+	SpringBootBuildInfoGoal = "spring-boot:build-info"
+)
+
+// IsSpringBoot determines if the POM builds a Spring Boot application, either inheriting from the Spring Boot parent
+// POM or declaring the Spring Boot Maven plugin
+func (p POM) IsSpringBoot() bool {
+	if strings.TrimSpace(p.Parent.GroupID) == SpringBootGroupID &&
+		strings.TrimSpace(p.Parent.ArtifactID) == SpringBootParentArtifactID {
+		return true
+	}
+	return p.HasPlugin(SpringBootGroupID, SpringBootPluginArtifactID)
+}
+
+// BuildInfoArguments returns the arguments with the build-info goal added before the goals and phases that build the
+// application, but after any clean, which would delete the build information it generates.
+func BuildInfoArguments(args []string) []string {
+	i := 0
+	for j := 0; j < len(args); j++ {
+		name := args[j]
+		if long, ok := mavenShortOptions[name]; ok {
+			name = long
+		}
+		if mavenValueOptions[name] {
+			// the value of an option, e.g. a profile, is not a goal
+			j++
+		} else if name == "clean" || strings.HasPrefix(name, "clean:") {
+			i = j + 1
+		}
+	}
+
+	return append(append(append([]string{}, args[:i]...), SpringBootBuildInfoGoal), args[i:]...)
+}
+
+// SpringBootArtifactPattern returns the pattern matching the executable archive repackaged by the Spring Boot Maven
+// plugin.  The archive is named after the final name of the build, followed by the classifier of the plugin if it
+// has one, in which case the archive without the classifier is the plain one.  If the final name cannot be resolved
+// from the POM alone, any archive with the classifier matches.
+func SpringBootArtifactPattern(pom POM) string {
+	name := strings.TrimSpace(pom.FinalName)
+	if name == "" {
+		name = "${project.artifactId}-${project.version}"
+	}
+	if name = pom.Interpolate(name); strings.Contains(name, "${") {
+		name = "*"
+	}
+
+	if plugin, ok := pom.Plugin(SpringBootGroupID, SpringBootPluginArtifactID); ok {
+		if classifier := pom.Interpolate(strings.TrimSpace(plugin.Classifier)); classifier != "" && !strings.Contains(classifier, "${") {
+			name = fmt.Sprintf("%s-%s", name, classifier)
+		}
+	}
+
+	extension := "jar"
+	if strings.TrimSpace(pom.Packaging) == "war" {
+		extension = "war"
+	}
+
+	return filepath.Join("target", fmt.Sprintf("%s.%s", name, extension))
+}
+
+// SecondaryClassifiers are the classifiers of the archives built next to an application that are not the application
+var SecondaryClassifiers = []string{"plain", "sources", "javadoc", "tests", "test-sources"}
+
+// IsSecondaryArchive determines if an archive is classified with one of SecondaryClassifiers
+func IsSecondaryArchive(file string) bool {
+	name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	for _, c := range SecondaryClassifiers {
+		if strings.HasSuffix(name, "-"+c) {
+			return true
+		}
+	}
+	return false
+}
+
+// SecondaryArchiveRemovingExecutor is an implementation of effect.Executor that removes the secondary archives matching
+// Pattern built by Maven, so that the application is the only archive the pattern matches.  Nothing is removed if
+// only secondary archives match.
+type SecondaryArchiveRemovingExecutor struct {
+	Delegate effect.Executor
+	Logger   bard.Logger
+	Pattern  string
+}
+
+func (s SecondaryArchiveRemovingExecutor) Execute(execution effect.Execution) error {
+	if err := s.Delegate.Execute(execution); err != nil {
+		return err
+	}
+
+	candidates, err := filepath.Glob(s.Pattern)
+	if err != nil {
+		return fmt.Errorf("unable to find files with %s\n%w", s.Pattern, err)
+	}
+
+	var applications, secondary []string
+	for _, c := range candidates {
+		if IsSecondaryArchive(c) {
+			secondary = append(secondary, c)
+		} else {
+			applications = append(applications, c)
+		}
+	}
+	if len(applications) == 0 {
+		return nil
+	}
+
+	for _, c := range secondary {
+		s.Logger.Bodyf("Removing %s, it is not the application", filepath.Base(c))
+		if err := os.Remove(c); err != nil {
+			return fmt.Errorf("unable to remove %s\n%w", c, err)
+		}
+	}
+
+	return nil
+}
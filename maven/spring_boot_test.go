@@ -0,0 +1,129 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven_test
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/maven/v6/maven"
+)
+
+func testSpringBoot(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		plugin = maven.Plugin{GroupID: "org.springframework.boot", ArtifactID: "spring-boot-maven-plugin"}
+	)
+
+	it("recognizes a Spring Boot application", func() {
+		Expect(maven.POM{}.IsSpringBoot()).To(BeFalse())
+		Expect(maven.POM{Parent: maven.Parent{GroupID: "org.springframework.boot", ArtifactID: "spring-boot-starter-parent"}}.IsSpringBoot()).To(BeTrue())
+		Expect(maven.POM{Plugins: []maven.Plugin{plugin}}.IsSpringBoot()).To(BeTrue())
+	})
+
+	it("matches the archive named after the artifactId and version", func() {
+		pom := maven.POM{ArtifactID: "demo", Parent: maven.Parent{Version: "1.0.0"}, Plugins: []maven.Plugin{plugin}}
+		Expect(maven.SpringBootArtifactPattern(pom)).To(Equal("target/demo-1.0.0.jar"))
+
+		pom.Version = "${revision}"
+		pom.Properties = maven.Properties{Entries: []maven.Property{{XMLName: xml.Name{Local: "revision"}, Value: "2.0.0"}}}
+		Expect(maven.SpringBootArtifactPattern(pom)).To(Equal("target/demo-2.0.0.jar"))
+	})
+
+	it("matches the archive named after the final name", func() {
+		pom := maven.POM{FinalName: "${project.artifactId}", ArtifactID: "demo", Packaging: "war"}
+		Expect(maven.SpringBootArtifactPattern(pom)).To(Equal("target/demo.war"))
+	})
+
+	it("matches the archive with the classifier of the plugin", func() {
+		plugin.Classifier = "exec"
+		pom := maven.POM{ArtifactID: "demo", Version: "1.0.0", Plugins: []maven.Plugin{plugin}}
+		Expect(maven.SpringBootArtifactPattern(pom)).To(Equal("target/demo-1.0.0-exec.jar"))
+	})
+
+	it("matches any archive if the final name cannot be resolved", func() {
+		pom := maven.POM{ArtifactID: "demo", Version: "${revision}"}
+		Expect(maven.SpringBootArtifactPattern(pom)).To(Equal("target/*.jar"))
+	})
+
+	it("generates the build information after any clean", func() {
+		Expect(maven.BuildInfoArguments([]string{"package"})).To(Equal([]string{"spring-boot:build-info", "package"}))
+		Expect(maven.BuildInfoArguments([]string{"clean", "package"})).To(Equal([]string{"clean", "spring-boot:build-info", "package"}))
+		Expect(maven.BuildInfoArguments([]string{"-B", "clean:clean", "-DskipTests", "install"})).
+			To(Equal([]string{"-B", "clean:clean", "spring-boot:build-info", "-DskipTests", "install"}))
+		Expect(maven.BuildInfoArguments([]string{"-P", "clean", "package"})).To(Equal([]string{"spring-boot:build-info", "-P", "clean", "package"}))
+		Expect(maven.BuildInfoArguments([]string{"clean"})).To(Equal([]string{"clean", "spring-boot:build-info"}))
+	})
+
+	context("SecondaryArchiveRemovingExecutor", func() {
+		var dir string
+
+		it.Before(func() {
+			var err error
+			dir, err = os.MkdirTemp("", "spring-boot")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(dir)).To(Succeed())
+		})
+
+		it("removes the secondary archives after a successful build", func() {
+			for _, name := range []string{"demo-1.0.0.jar", "demo-1.0.0-plain.jar", "demo-1.0.0-sources.jar", "demo-1.0.0-javadoc.jar", "demo-1.0.0.jar.original"} {
+				Expect(os.WriteFile(filepath.Join(dir, name), []byte{}, 0644)).To(Succeed())
+			}
+
+			err := maven.SecondaryArchiveRemovingExecutor{Delegate: &RecordingExecutor{}, Pattern: filepath.Join(dir, "*.jar")}.
+				Execute(effect.Execution{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(filepath.Glob(filepath.Join(dir, "*"))).To(ConsistOf(
+				filepath.Join(dir, "demo-1.0.0.jar"),
+				filepath.Join(dir, "demo-1.0.0.jar.original"),
+			))
+		})
+
+		it("keeps the secondary archives when no other archive matches", func() {
+			Expect(os.WriteFile(filepath.Join(dir, "demo-1.0.0-sources.jar"), []byte{}, 0644)).To(Succeed())
+
+			err := maven.SecondaryArchiveRemovingExecutor{Delegate: &RecordingExecutor{}, Pattern: filepath.Join(dir, "*.jar")}.
+				Execute(effect.Execution{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(filepath.Join(dir, "demo-1.0.0-sources.jar")).To(BeARegularFile())
+		})
+
+		it("does not remove anything when the build fails", func() {
+			Expect(os.WriteFile(filepath.Join(dir, "demo-1.0.0.jar"), []byte{}, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "demo-1.0.0-sources.jar"), []byte{}, 0644)).To(Succeed())
+
+			err := maven.SecondaryArchiveRemovingExecutor{Delegate: &RecordingExecutor{Err: fmt.Errorf("test-error")}, Pattern: filepath.Join(dir, "*.jar")}.
+				Execute(effect.Execution{})
+			Expect(err).To(MatchError("test-error"))
+
+			Expect(filepath.Join(dir, "demo-1.0.0-sources.jar")).To(BeARegularFile())
+		})
+	})
+}
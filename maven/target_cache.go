@@ -0,0 +1,308 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/paketo-buildpacks/libpak/sherpa"
+)
+
+// TargetCache restores the target directories of each module from a previous build.  The cache is discarded when
+// the JDK or Maven version or any POM of the project changes, as the compiled classes are not guaranteed to be
+// compatible and a module inherits its configuration from the POMs of its parents.  A module's target directory is
+// only restored if its sources are unchanged, as the restored files are newer than any source and the stale-source
+// detection of the compiler would consider edited sources up to date.
+type TargetCache struct {
+	ApplicationPath  string
+	LayerContributor libpak.LayerContributor
+	Logger           bard.Logger
+}
+
+func NewTargetCache(applicationPath string, jdkVersion string, mavenVersion string) (TargetCache, error) {
+	poms, err := pomsDigest(applicationPath)
+	if err != nil {
+		return TargetCache{}, fmt.Errorf("unable to compute the digest of the POMs in %s\n%w", applicationPath, err)
+	}
+
+	return TargetCache{
+		ApplicationPath: applicationPath,
+		LayerContributor: libpak.NewLayerContributor("Module target directories", map[string]interface{}{
+			"jdk-version":   jdkVersion,
+			"maven-version": mavenVersion,
+			"poms-sha256":   poms,
+		}, libcnb.LayerTypes{Cache: true}),
+	}, nil
+}
+
+func (t TargetCache) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
+	t.LayerContributor.Logger = t.Logger
+
+	layer, err := t.LayerContributor.Contribute(layer, func() (libcnb.Layer, error) {
+		t.Logger.Body("No target directories to restore")
+		return layer, nil
+	})
+	if err != nil {
+		return libcnb.Layer{}, err
+	}
+
+	modules, err := targetDirectories(layer.Path, false)
+	if err != nil {
+		return libcnb.Layer{}, fmt.Errorf("unable to list cached target directories\n%w", err)
+	}
+
+	for _, m := range modules {
+		destination := filepath.Join(t.ApplicationPath, m)
+		if _, err := os.Stat(destination); err == nil {
+			continue
+		}
+
+		if digest, err := os.ReadFile(sourcesDigestFile(filepath.Join(layer.Path, m))); err != nil {
+			t.Logger.Bodyf("Not restoring %s, the digest of its sources is unknown", m)
+			continue
+		} else if current, err := sourcesDigest(filepath.Dir(destination)); err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to compute the digest of the sources of %s\n%w", m, err)
+		} else if string(digest) != current {
+			t.Logger.Bodyf("Not restoring %s, its sources changed", m)
+			continue
+		}
+
+		t.Logger.Bodyf("Restoring %s", m)
+		if err := sherpa.CopyDir(filepath.Join(layer.Path, m), destination); err != nil {
+			return libcnb.Layer{}, fmt.Errorf("unable to restore %s\n%w", m, err)
+		}
+	}
+
+	return layer, nil
+}
+
+func (TargetCache) Name() string {
+	return "target-cache"
+}
+
+// TargetCachingExecutor saves the target directories of each module to the target cache layer once Maven has built
+// them successfully, before the source code is removed.  They are relative to ApplicationPath, as they are restored
+// by the TargetCache.
+type TargetCachingExecutor struct {
+	ApplicationPath string
+	Delegate        effect.Executor
+	LayerPath       string
+	Logger          bard.Logger
+}
+
+func (t TargetCachingExecutor) Execute(execution effect.Execution) error {
+	if err := t.Delegate.Execute(execution); err != nil {
+		return err
+	}
+
+	modules, err := targetDirectories(t.ApplicationPath, true)
+	if err != nil {
+		t.Logger.Bodyf("WARNING: unable to list target directories\n%s", err)
+		return nil
+	}
+
+	for _, m := range modules {
+		destination := filepath.Join(t.LayerPath, m)
+		if err := os.RemoveAll(destination); err != nil {
+			t.Logger.Bodyf("WARNING: unable to remove %s\n%s", destination, err)
+			continue
+		}
+		digest, err := sourcesDigest(filepath.Join(t.ApplicationPath, filepath.Dir(m)))
+		if err != nil {
+			t.Logger.Bodyf("WARNING: unable to compute the digest of the sources of %s\n%s", m, err)
+			continue
+		}
+		if err := sherpa.CopyDir(filepath.Join(t.ApplicationPath, m), destination); err != nil {
+			t.Logger.Bodyf("WARNING: unable to cache %s\n%s", m, err)
+			continue
+		}
+		if err := os.WriteFile(sourcesDigestFile(destination), []byte(digest), 0644); err != nil {
+			t.Logger.Bodyf("WARNING: unable to write the digest of the sources of %s\n%s", m, err)
+		}
+	}
+
+	return nil
+}
+
+// targetDirectories returns the target directories under root, relative to it.  If modulesOnly is set, only the
+// target directories next to a POM are returned.
+func targetDirectories(root string, modulesOnly bool) ([]string, error) {
+	var targets []string
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+
+		switch d.Name() {
+		case ".git", ".mvn", "node_modules":
+			return filepath.SkipDir
+		case "target":
+			if !modulesOnly || isModule(filepath.Dir(path)) {
+				rel, err := filepath.Rel(root, path)
+				if err != nil {
+					return err
+				}
+				targets = append(targets, rel)
+			}
+			return filepath.SkipDir
+		}
+
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	return targets, err
+}
+
+// sourcesDigestFile returns the file the digest of the sources of a cached target directory is kept in
+func sourcesDigestFile(target string) string {
+	return target + ".sources-sha256"
+}
+
+// sourcesDigest returns the SHA-256 digest of the sources of the module in dir: its POMs and every file under src
+func sourcesDigest(dir string) (string, error) {
+	var files []string
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("unable to list %s\n%w", dir, err)
+	}
+	for _, e := range entries {
+		if !e.IsDir() && isPOM(e.Name()) {
+			files = append(files, e.Name())
+		}
+	}
+
+	err = filepath.WalkDir(filepath.Join(dir, "src"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("unable to list the sources in %s\n%w", dir, err)
+	}
+
+	return filesDigest(dir, files)
+}
+
+// pomsDigest returns the SHA-256 digest of every POM under root.  A module inherits from the POMs of its parents and
+// the reactor root, so that their changes are not seen by the digest of its sources.
+func pomsDigest(root string) (string, error) {
+	var files []string
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			switch d.Name() {
+			case ".git", ".mvn", "node_modules", "target":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() && isPOM(d.Name()) {
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("unable to list the POMs in %s\n%w", root, err)
+	}
+
+	return filesDigest(root, files)
+}
+
+// filesDigest returns the SHA-256 digest of the names and contents of files, relative to dir
+func filesDigest(dir string, files []string) (string, error) {
+	sort.Strings(files)
+
+	hash := sha256.New()
+	for _, f := range files {
+		in, err := os.Open(filepath.Join(dir, f))
+		if err != nil {
+			return "", fmt.Errorf("unable to open %s\n%w", f, err)
+		}
+		// the name is part of the digest, so that moving a file changes it
+		_, _ = fmt.Fprintf(hash, "%s\x00", filepath.ToSlash(f))
+		_, err = io.Copy(hash, in)
+		in.Close()
+		if err != nil {
+			return "", fmt.Errorf("unable to read %s\n%w", f, err)
+		}
+		_, _ = hash.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// isModule determines if a directory contains a POM
+func isModule(path string) bool {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if !e.IsDir() && isPOM(e.Name()) {
+			return true
+		}
+	}
+	return false
+}
+
+// jdkVersion returns the version of the JDK at $JAVA_HOME, or an empty string if it is unknown
+func jdkVersion() string {
+	b, err := os.ReadFile(filepath.Join(os.Getenv("JAVA_HOME"), "release"))
+	if err != nil {
+		return ""
+	}
+
+	if m := regexp.MustCompile(`(?m)^JAVA_VERSION="?([^"\n]+)"?`).FindSubmatch(b); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
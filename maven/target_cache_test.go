@@ -0,0 +1,183 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/maven/v6/maven"
+)
+
+func testTargetCache(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		ctx libcnb.BuildContext
+	)
+
+	it.Before(func() {
+		var err error
+
+		ctx.Application.Path, err = os.MkdirTemp("", "target-cache-application")
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx.Layers.Path, err = os.MkdirTemp("", "target-cache-layers")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(ctx.Application.Path)).To(Succeed())
+		Expect(os.RemoveAll(ctx.Layers.Path)).To(Succeed())
+	})
+
+	targetCache := func(jdkVersion string) maven.TargetCache {
+		tc, err := maven.NewTargetCache(ctx.Application.Path, jdkVersion, "3.9.9")
+		Expect(err).NotTo(HaveOccurred())
+		return tc
+	}
+
+	it("saves module target directories after a successful build", func() {
+		layerPath := filepath.Join(ctx.Layers.Path, "target-cache")
+		Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "target", "classes"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte{}, 0644)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "core", "target"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "core", "pom.xml"), []byte{}, 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "core", "target", "core.jar"), []byte("test"), 0644)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "docs", "target"), 0755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(layerPath, "core", "target", "stale"), 0755)).To(Succeed())
+
+		err := maven.TargetCachingExecutor{ApplicationPath: ctx.Application.Path, Delegate: &RecordingExecutor{}, LayerPath: layerPath}.
+			Execute(effect.Execution{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(filepath.Join(layerPath, "target", "classes")).To(BeADirectory())
+		Expect(filepath.Join(layerPath, "core", "target", "core.jar")).To(BeARegularFile())
+		Expect(filepath.Join(layerPath, "core", "target", "stale")).NotTo(BeADirectory())
+		Expect(filepath.Join(layerPath, "docs")).NotTo(BeADirectory())
+	})
+
+	it("does not save target directories when the build fails", func() {
+		layerPath := filepath.Join(ctx.Layers.Path, "target-cache")
+		Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "target"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte{}, 0644)).To(Succeed())
+
+		err := maven.TargetCachingExecutor{ApplicationPath: ctx.Application.Path, Delegate: &RecordingExecutor{Err: fmt.Errorf("test-error")}, LayerPath: layerPath}.
+			Execute(effect.Execution{})
+		Expect(err).To(MatchError("test-error"))
+
+		Expect(layerPath).NotTo(BeADirectory())
+	})
+
+	it("restores target directories", func() {
+		Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "core", "src", "main", "java"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "core", "pom.xml"), []byte("<project/>"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "core", "src", "main", "java", "A.java"), []byte("class A {}"), 0644)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "core", "target"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "core", "target", "core.jar"), []byte("test"), 0644)).To(Succeed())
+
+		layer, err := ctx.Layers.Layer("target-cache")
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err = targetCache("17.0.1").Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(layer.Cache).To(BeTrue())
+
+		Expect(maven.TargetCachingExecutor{ApplicationPath: ctx.Application.Path, Delegate: &RecordingExecutor{}, LayerPath: layer.Path}.
+			Execute(effect.Execution{})).To(Succeed())
+		Expect(os.RemoveAll(filepath.Join(ctx.Application.Path, "core", "target"))).To(Succeed())
+
+		_, err = targetCache("17.0.1").Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(filepath.Join(ctx.Application.Path, "core", "target", "core.jar")).To(BeARegularFile())
+	})
+
+	it("does not restore target directories when the sources change between builds", func() {
+		source := filepath.Join(ctx.Application.Path, "src", "main", "java", "A.java")
+		Expect(os.MkdirAll(filepath.Dir(source), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte("<project/>"), 0644)).To(Succeed())
+		Expect(os.WriteFile(source, []byte("class A {}"), 0644)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "target", "classes"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "target", "classes", "A.class"), []byte("stale"), 0644)).To(Succeed())
+
+		layer, err := ctx.Layers.Layer("target-cache")
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err = targetCache("17.0.1").Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(maven.TargetCachingExecutor{ApplicationPath: ctx.Application.Path, Delegate: &RecordingExecutor{}, LayerPath: layer.Path}.
+			Execute(effect.Execution{})).To(Succeed())
+		Expect(os.RemoveAll(filepath.Join(ctx.Application.Path, "target"))).To(Succeed())
+
+		Expect(os.WriteFile(source, []byte("class A { int a; }"), 0644)).To(Succeed())
+
+		_, err = targetCache("17.0.1").Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(filepath.Join(ctx.Application.Path, "target")).NotTo(BeADirectory())
+	})
+
+	it("discards target directories when the JDK version changes", func() {
+		layer, err := ctx.Layers.Layer("target-cache")
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err = targetCache("17.0.1").Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Join(layer.Path, "core", "target"), 0755)).To(Succeed())
+
+		layer, err = targetCache("21.0.2").Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(filepath.Join(layer.Path, "core")).NotTo(BeADirectory())
+		Expect(filepath.Join(ctx.Application.Path, "core")).NotTo(BeADirectory())
+	})
+
+	it("discards target directories when a parent POM changes", func() {
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte("<project/>"), 0644)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(ctx.Application.Path, "core", "target"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "core", "pom.xml"), []byte("<project/>"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "core", "target", "core.jar"), []byte("test"), 0644)).To(Succeed())
+
+		layer, err := ctx.Layers.Layer("target-cache")
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err = targetCache("17.0.1").Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(maven.TargetCachingExecutor{ApplicationPath: ctx.Application.Path, Delegate: &RecordingExecutor{}, LayerPath: layer.Path}.
+			Execute(effect.Execution{})).To(Succeed())
+		Expect(os.RemoveAll(filepath.Join(ctx.Application.Path, "core", "target"))).To(Succeed())
+
+		Expect(os.WriteFile(filepath.Join(ctx.Application.Path, "pom.xml"), []byte("<project><properties><maven.compiler.release>21</maven.compiler.release></properties></project>"), 0644)).To(Succeed())
+
+		layer, err = targetCache("17.0.1").Contribute(layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(filepath.Join(layer.Path, "core")).NotTo(BeADirectory())
+		Expect(filepath.Join(ctx.Application.Path, "core", "target")).NotTo(BeADirectory())
+	})
+}
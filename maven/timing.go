@@ -0,0 +1,260 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/buildpacks/libcnb"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/effect"
+)
+
+// The phases of a build that are timed.  The cache setup links the cached local repository, which is restored by the
+// lifecycle before the build, and restores the target directories if they are cached.
+const (
+	PhaseMavenSelection    = "Maven selection"
+	PhaseMavenInstallation = "Maven installation"
+	PhaseCacheSetup        = "Cache setup"
+	PhaseMavenExecution    = "Maven execution"
+	PhasePostBuild         = "Artifact resolution and source removal"
+)
+
+// PhaseTiming is the duration of a single build step
+type PhaseTiming struct {
+	Name     string        `json:"name" toml:"name"`
+	Duration time.Duration `json:"-" toml:"-"`
+	Seconds  float64       `json:"seconds" toml:"seconds"`
+}
+
+// ModuleTiming is the duration of a single reactor module, as reported by Maven
+type ModuleTiming struct {
+	Name     string        `json:"name" toml:"name"`
+	Status   string        `json:"status" toml:"status"`
+	Duration time.Duration `json:"-" toml:"-"`
+	Seconds  float64       `json:"seconds" toml:"seconds"`
+}
+
+// BuildTimer records the duration of each step of a build.  It is shared between the layer contributors of a build
+// so it must be passed by reference.
+type BuildTimer struct {
+	Phases  []PhaseTiming  `json:"phases" toml:"phases"`
+	Modules []ModuleTiming `json:"modules" toml:"modules"`
+	Seconds float64        `json:"total-seconds" toml:"total-seconds"`
+
+	Now func() time.Time `json:"-" toml:"-"`
+}
+
+func NewBuildTimer() *BuildTimer {
+	return &BuildTimer{
+		Phases:  []PhaseTiming{},
+		Modules: []ModuleTiming{},
+		Now:     time.Now,
+	}
+}
+
+// Time starts timing a phase, the returned function stops it and records the duration
+func (b *BuildTimer) Time(name string) func() {
+	start := b.Now()
+	return func() {
+		b.Record(name, b.Now().Sub(start))
+	}
+}
+
+// Record adds the duration of a phase
+func (b *BuildTimer) Record(name string, duration time.Duration) {
+	b.Phases = append(b.Phases, PhaseTiming{Name: name, Duration: duration, Seconds: duration.Seconds()})
+	b.Seconds += duration.Seconds()
+}
+
+// RecordModule adds the duration of a reactor module
+func (b *BuildTimer) RecordModule(name string, status string, duration time.Duration) {
+	b.Modules = append(b.Modules, ModuleTiming{Name: name, Status: status, Duration: duration, Seconds: duration.Seconds()})
+}
+
+// Duration returns the total recorded duration of a phase
+func (b *BuildTimer) Duration(name string) time.Duration {
+	var d time.Duration
+	for _, p := range b.Phases {
+		if p.Name == name {
+			d += p.Duration
+		}
+	}
+	return d
+}
+
+// TimedLayerContributor records the time taken to contribute a layer as a build phase.  If Remainder is set, the
+// time not already accounted for by phases recorded during the contribution is recorded under that name instead.
+type TimedLayerContributor struct {
+	libcnb.LayerContributor
+	Phase     string
+	Remainder string
+	Timer     *BuildTimer
+}
+
+func (t TimedLayerContributor) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
+	if t.Remainder == "" {
+		defer t.Timer.Time(t.Phase)()
+		return t.LayerContributor.Contribute(layer)
+	}
+
+	start, recorded := t.Timer.Now(), t.Timer.Seconds
+	defer func() {
+		elapsed := t.Timer.Now().Sub(start)
+		nested := time.Duration((t.Timer.Seconds - recorded) * float64(time.Second))
+		t.Timer.Record(t.Remainder, elapsed-nested)
+	}()
+	return t.LayerContributor.Contribute(layer)
+}
+
+// TimedExecutor records the time taken to run Maven and the per-module durations from Maven's reactor summary
+type TimedExecutor struct {
+	Delegate effect.Executor
+	Timer    *BuildTimer
+}
+
+func (t TimedExecutor) Execute(execution effect.Execution) error {
+	w := NewLineWriter(execution.Stdout, t.Timer.ObserveLine)
+	execution.Stdout = w
+	defer w.Flush()
+	defer t.Timer.Time(PhaseMavenExecution)()
+
+	return t.Delegate.Execute(execution)
+}
+
+var reactorSummaryLine = regexp.MustCompile(`^\[INFO\] (.+?) \.+ ?(SUCCESS|FAILURE|SKIPPED)(?: \[\s*([0-9.:]+) (ms|s|min|h)\])?`)
+
+// ObserveLine records the module duration from a Maven reactor summary line
+func (b *BuildTimer) ObserveLine(line string) {
+	m := reactorSummaryLine.FindStringSubmatch(line)
+	if m == nil {
+		return
+	}
+
+	d, err := parseMavenDuration(m[3], m[4])
+	if err != nil {
+		return
+	}
+	b.RecordModule(m[1], m[2], d)
+}
+
+// parseMavenDuration parses the durations Maven prints in its reactor summary, e.g. `1.234 s`, `01:02 min`, `1:02 h`
+func parseMavenDuration(value string, unit string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	switch unit {
+	case "ms":
+		v, err := strconv.ParseFloat(value, 64)
+		return time.Duration(v * float64(time.Millisecond)), err
+	case "s":
+		v, err := strconv.ParseFloat(value, 64)
+		return time.Duration(v * float64(time.Second)), err
+	case "min", "h":
+		major, minor, found := strings.Cut(value, ":")
+		if !found {
+			return 0, fmt.Errorf("unable to parse duration %s %s", value, unit)
+		}
+		ma, err := strconv.Atoi(major)
+		if err != nil {
+			return 0, err
+		}
+		mi, err := strconv.ParseFloat(minor, 64)
+		if err != nil {
+			return 0, err
+		}
+		if unit == "min" {
+			return time.Duration(ma)*time.Minute + time.Duration(mi*float64(time.Second)), nil
+		}
+		return time.Duration(ma)*time.Hour + time.Duration(mi*float64(time.Minute)), nil
+	}
+
+	return 0, fmt.Errorf("unknown duration unit %s", unit)
+}
+
+// Report is written once a build is done
+type Report interface {
+	Write() error
+}
+
+// ReportingLayerContributor writes reports once the layer it decorates, the last layer of a build, is contributed, so
+// that every step of the build is done and the reports do not need a layer of their own.  The reports are written
+// even if the contribution fails.
+type ReportingLayerContributor struct {
+	libcnb.LayerContributor
+	Reports []Report
+}
+
+func (r ReportingLayerContributor) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
+	layer, err := r.LayerContributor.Contribute(layer)
+	for _, report := range r.Reports {
+		if rerr := report.Write(); rerr != nil && err == nil {
+			err = rerr
+		}
+	}
+	if err != nil {
+		return libcnb.Layer{}, err
+	}
+
+	return layer, nil
+}
+
+// TimingReport logs a summary of the recorded timings and optionally writes them as JSON
+type TimingReport struct {
+	Logger bard.Logger
+	Path   string
+	Timer  *BuildTimer
+}
+
+func (t TimingReport) Write() error {
+	t.Logger.Header("Build timings")
+	for _, p := range t.Timer.Phases {
+		t.Logger.Bodyf("%-40s %10.3fs", p.Name, p.Seconds)
+	}
+	for _, m := range t.Timer.Modules {
+		t.Logger.Bodyf("  %-38s %10.3fs %s", m.Name, m.Seconds, m.Status)
+	}
+	t.Logger.Bodyf("%-40s %10.3fs", "Total", t.Timer.Seconds)
+
+	if t.Path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(t.Path), 0755); err != nil {
+		return fmt.Errorf("unable to create directory for %s\n%w", t.Path, err)
+	}
+
+	b, err := json.MarshalIndent(t.Timer, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode build timings\n%w", err)
+	}
+
+	if err := os.WriteFile(t.Path, b, 0644); err != nil {
+		return fmt.Errorf("unable to write build timings to %s\n%w", t.Path, err)
+	}
+	t.Logger.Bodyf("Wrote build timings to %s", t.Path)
+
+	return nil
+}
@@ -0,0 +1,217 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maven_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/buildpacks/libcnb"
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/libpak/bard"
+	"github.com/paketo-buildpacks/libpak/effect"
+	"github.com/sclevine/spec"
+
+	"github.com/paketo-buildpacks/maven/v6/maven"
+)
+
+func testTiming(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		timer *maven.BuildTimer
+		now   time.Time
+	)
+
+	it.Before(func() {
+		now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		timer = maven.NewBuildTimer()
+		timer.Now = func() time.Time { return now }
+	})
+
+	it("records phases", func() {
+		stop := timer.Time(maven.PhaseMavenSelection)
+		now = now.Add(2 * time.Second)
+		stop()
+
+		Expect(timer.Phases).To(HaveLen(1))
+		Expect(timer.Phases[0].Name).To(Equal(maven.PhaseMavenSelection))
+		Expect(timer.Phases[0].Duration).To(Equal(2 * time.Second))
+		Expect(timer.Seconds).To(Equal(2.0))
+	})
+
+	it("records the remainder of a layer contribution", func() {
+		contributor := TimedFakeLayerContributor{Run: func() {
+			timer.Record(maven.PhaseMavenExecution, 5*time.Second)
+			now = now.Add(7 * time.Second)
+		}}
+
+		_, err := maven.TimedLayerContributor{
+			LayerContributor: contributor,
+			Remainder:        maven.PhasePostBuild,
+			Timer:            timer,
+		}.Contribute(libcnb.Layer{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(timer.Duration(maven.PhaseMavenExecution)).To(Equal(5 * time.Second))
+		Expect(timer.Duration(maven.PhasePostBuild)).To(Equal(2 * time.Second))
+		Expect(timer.Seconds).To(Equal(7.0))
+	})
+
+	context("ReportingLayerContributor", func() {
+		var steps []string
+
+		it.Before(func() {
+			steps = nil
+		})
+
+		it("writes the reports once the layer is contributed", func() {
+			layer, err := maven.ReportingLayerContributor{
+				LayerContributor: TimedFakeLayerContributor{Run: func() { steps = append(steps, "contribute") }},
+				Reports:          []maven.Report{FakeReport{Run: func() { steps = append(steps, "report") }}},
+			}.Contribute(libcnb.Layer{Name: "fake"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(layer.Name).To(Equal("fake"))
+			Expect(steps).To(Equal([]string{"contribute", "report"}))
+		})
+
+		it("writes the reports when the contribution fails", func() {
+			_, err := maven.ReportingLayerContributor{
+				LayerContributor: TimedFakeLayerContributor{Run: func() { steps = append(steps, "contribute") }, Err: errors.New("test-error")},
+				Reports:          []maven.Report{FakeReport{Run: func() { steps = append(steps, "report") }}},
+			}.Contribute(libcnb.Layer{Name: "fake"})
+			Expect(err).To(MatchError("test-error"))
+
+			Expect(steps).To(Equal([]string{"contribute", "report"}))
+		})
+	})
+
+	it("records module durations from the reactor summary", func() {
+		out := &bytes.Buffer{}
+		executor := maven.TimedExecutor{
+			Delegate: FakeExecutor{Output: "[INFO] Reactor Summary for parent 1.0:\n" +
+				"[INFO] \n" +
+				"[INFO] parent ............................................. SUCCESS [  0.123 s]\n" +
+				"[INFO] \x1b[1mcore\x1b[m ....... SUCCESS [01:02 min]\n" +
+				"[INFO] web ................................................ FAILURE [  1.5 s]\n" +
+				"[INFO] cli ................................................ SKIPPED\n" +
+				"[INFO] BUILD FAILURE"},
+			Timer: timer,
+		}
+
+		Expect(executor.Execute(effect.Execution{Stdout: out})).To(Succeed())
+
+		Expect(out.String()).To(ContainSubstring("BUILD FAILURE"))
+		Expect(timer.Modules).To(Equal([]maven.ModuleTiming{
+			{Name: "parent", Status: "SUCCESS", Duration: 123 * time.Millisecond, Seconds: 0.123},
+			{Name: "core", Status: "SUCCESS", Duration: 62 * time.Second, Seconds: 62},
+			{Name: "web", Status: "FAILURE", Duration: 1500 * time.Millisecond, Seconds: 1.5},
+			{Name: "cli", Status: "SKIPPED"},
+		}))
+		Expect(timer.Phases).To(HaveLen(1))
+		Expect(timer.Phases[0].Name).To(Equal(maven.PhaseMavenExecution))
+	})
+
+	context("TimingReport", func() {
+		var path string
+
+		it.Before(func() {
+			var err error
+			path, err = os.MkdirTemp("", "timing-report")
+			Expect(err).NotTo(HaveOccurred())
+
+			timer.Record(maven.PhaseMavenExecution, 3*time.Second)
+			timer.RecordModule("core", "SUCCESS", time.Second)
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(path)).To(Succeed())
+		})
+
+		it("logs a summary", func() {
+			out := &bytes.Buffer{}
+
+			Expect(maven.TimingReport{Logger: bard.NewLogger(out), Timer: timer}.Write()).To(Succeed())
+
+			Expect(out.String()).To(ContainSubstring("Build timings"))
+			Expect(out.String()).To(MatchRegexp(`Maven execution\s+3.000s`))
+			Expect(out.String()).To(MatchRegexp(`core\s+1.000s SUCCESS`))
+			Expect(out.String()).To(MatchRegexp(`Total\s+3.000s`))
+		})
+
+		it("writes a JSON report", func() {
+			file := filepath.Join(path, "reports", "timings.json")
+
+			Expect(maven.TimingReport{Logger: bard.NewLogger(&bytes.Buffer{}), Path: file, Timer: timer}.Write()).To(Succeed())
+
+			b, err := os.ReadFile(file)
+			Expect(err).NotTo(HaveOccurred())
+
+			var report map[string]interface{}
+			Expect(json.Unmarshal(b, &report)).To(Succeed())
+			Expect(report["total-seconds"]).To(Equal(3.0))
+			Expect(report["phases"]).To(Equal([]interface{}{
+				map[string]interface{}{"name": "Maven execution", "seconds": 3.0},
+			}))
+			Expect(report["modules"]).To(Equal([]interface{}{
+				map[string]interface{}{"name": "core", "status": "SUCCESS", "seconds": 1.0},
+			}))
+		})
+	})
+}
+
+type FakeExecutor struct {
+	Output string
+	Err    error
+}
+
+func (f FakeExecutor) Execute(execution effect.Execution) error {
+	if _, err := fmt.Fprint(execution.Stdout, f.Output); err != nil {
+		return err
+	}
+	return f.Err
+}
+
+type TimedFakeLayerContributor struct {
+	Run func()
+	Err error
+}
+
+func (f TimedFakeLayerContributor) Contribute(layer libcnb.Layer) (libcnb.Layer, error) {
+	f.Run()
+	return layer, f.Err
+}
+
+func (TimedFakeLayerContributor) Name() string {
+	return "fake"
+}
+
+type FakeReport struct {
+	Run func()
+}
+
+func (f FakeReport) Write() error {
+	f.Run()
+	return nil
+}